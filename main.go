@@ -1,19 +1,35 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/gofiber/contrib/swagger"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/healthcheck"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"leita/src/cli"
+	"leita/src/dataSources"
+	"leita/src/loggers"
 	. "leita/src/routes"
+	"leita/src/services"
+	"leita/src/tracing"
 	. "leita/src/utils"
 )
 
+const (
+	defaultShutdownTimeout = 30 * time.Second
+	// 채점이 끝난 뒤 남은 응답과 연결을 정리하는 시간
+	httpShutdownTimeout = 5 * time.Second
+)
+
 // @title		Leita API Docs
 // @BasePath	/api
 func main() {
@@ -22,12 +38,42 @@ func main() {
 		return
 	}
 
-	app := fiber.New()
+	// ./server import-package ... 처럼 인자가 있으면 서버 대신 관리 명령을 실행한다
+	if len(os.Args) > 1 {
+		if err := cli.Run(os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	bodyLimit, err := bodyLimit()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	app := fiber.New(fiber.Config{
+		BodyLimit: bodyLimit,
+	})
 
 	app.Use(recover.New())
-	app.Use(logger.New())
-	app.Use(cors.New())
-	app.Use(healthcheck.New())
+	app.Use(logger.New(logger.Config{
+		Format:     loggers.AccessLogFormat(),
+		TimeFormat: time.RFC3339,
+	}))
+	// JUDGE_CORS_ORIGINS 가 없으면 다른 출처의 브라우저 요청을 허용하지 않는다
+	if origins := GetEnv("JUDGE_CORS_ORIGINS"); origins != "" {
+		app.Use(cors.New(cors.Config{
+			AllowOrigins: origins,
+			AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-Api-Key, X-Api-Key-Id, X-Timestamp, X-Signature",
+		}))
+	}
 	app.Use(swagger.New(swagger.Config{
 		FilePath: "./docs/swagger.json",
 		Path:     "/api/swagger",
@@ -38,7 +84,87 @@ func main() {
 		return
 	}
 
-	log.Fatal(app.Listen(":" + os.Getenv("JUDGE_PORT")))
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		if err := app.Listen(":" + os.Getenv("JUDGE_PORT")); err != nil {
+			log.Error(err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	shutdown(app, shutdownTracing)
+}
+
+// 새 채점은 거절하면서 진행 중인 채점이 끝나기를 JUDGE_SHUTDOWN_TIMEOUT(기본 30s) 동안 기다린 뒤 서버를 닫는다
+func shutdown(app *fiber.App, shutdownTracing func(context.Context) error) {
+	timeout := defaultShutdownTimeout
+	if value := GetEnv("JUDGE_SHUTDOWN_TIMEOUT"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			log.Error("invalid JUDGE_SHUTDOWN_TIMEOUT: ", value)
+		} else {
+			timeout = parsed
+		}
+	}
+
+	log.Info("서버 종료 중, 진행 중인 채점을 최대 ", timeout, " 기다립니다")
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := services.Drain(ctx); err != nil {
+		log.Error(err)
+	}
+
+	// 강제로 종료한 채점도 워커가 큐에 돌려놓을 수 있도록 데이터 소스를 닫기 전에 잠시 기다린다
+	workerCtx, cancelWorkers := context.WithTimeout(context.Background(), httpShutdownTimeout)
+	defer cancelWorkers()
+	if err := services.WaitWorkers(workerCtx); err != nil {
+		log.Error(err)
+	}
+
+	// 다시 보내기를 기다리는 콜백은 dead letter 로 남기고, 보내는 중인 콜백만 기다린다
+	// 워커가 시간을 다 써도 콜백을 보낼 시간이 남도록 따로 기다린다
+	callbackCtx, cancelCallbacks := context.WithTimeout(context.Background(), httpShutdownTimeout)
+	defer cancelCallbacks()
+	if err := services.StopCallbacks(callbackCtx); err != nil {
+		log.Error(err)
+	}
+
+	if err := app.ShutdownWithTimeout(httpShutdownTimeout); err != nil {
+		log.Error(err)
+	}
+
+	if err := dataSources.CloseDataSource(); err != nil {
+		log.Error(err)
+	}
+
+	if err := dataSources.CloseRedis(); err != nil {
+		log.Error(err)
+	}
+
+	if err := shutdownTracing(context.Background()); err != nil {
+		log.Error(err)
+	}
+
+	log.Info("서버 종료 완료")
+}
+
+// 문제 패키지를 API 로 올릴 수 있도록 JUDGE_BODY_LIMIT(MB) 로 요청 본문 크기 제한을 늘릴 수 있다
+func bodyLimit() (int, error) {
+	value := GetEnv("JUDGE_BODY_LIMIT")
+	if value == "" {
+		return fiber.DefaultBodyLimit, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		return 0, fmt.Errorf("invalid JUDGE_BODY_LIMIT: %s", value)
+	}
+
+	return limit << 20, nil
 }
 
 func initialize() error {
@@ -47,5 +173,10 @@ func initialize() error {
 		return err
 	}
 
+	if err := loggers.Setup(); err != nil {
+		log.Fatal(err)
+		return err
+	}
+
 	return nil
 }
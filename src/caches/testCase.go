@@ -0,0 +1,328 @@
+package caches
+
+import (
+	"container/list"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v2/log"
+	"leita/src/metrics"
+	. "leita/src/utils"
+)
+
+const (
+	defaultTestCaseCacheDir     = "cache/testcases"
+	defaultTestCaseCacheMaxSize = 1024 // MB
+)
+
+type TestCaseCache struct {
+	root    string
+	maxSize int64
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	size    int64
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+type TestCaseCacheStats struct {
+	Hits    int64
+	Misses  int64
+	Entries int
+	Size    int64
+}
+
+type testCaseCacheEntry struct {
+	problemId int
+	version   string
+	size      int64
+	refs      int
+	ready     chan struct{}
+	err       error
+}
+
+var (
+	sharedTestCaseCache     *TestCaseCache
+	sharedTestCaseCacheErr  error
+	sharedTestCaseCacheOnce sync.Once
+)
+
+// GetTestCaseCache 는 같은 캐시 디렉터리를 여러 서비스가 나눠 쓰도록 하나의 캐시를 반환한다
+func GetTestCaseCache() (*TestCaseCache, error) {
+	sharedTestCaseCacheOnce.Do(func() {
+		sharedTestCaseCache, sharedTestCaseCacheErr = NewTestCaseCache()
+	})
+
+	return sharedTestCaseCache, sharedTestCaseCacheErr
+}
+
+func NewTestCaseCache() (*TestCaseCache, error) {
+	root := GetEnv("TESTCASE_CACHE_DIR")
+	if root == "" {
+		root = defaultTestCaseCacheDir
+	}
+
+	maxSize := int64(defaultTestCaseCacheMaxSize)
+	if value := GetEnv("TESTCASE_CACHE_MAX_SIZE"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		maxSize = parsed
+	}
+
+	if err := MakeDir(root); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	cache := &TestCaseCache{
+		root:    root,
+		maxSize: maxSize << 20,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+
+	if err := cache.load(); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return cache, nil
+}
+
+// Acquire 는 problemId, version 에 해당하는 테스트 케이스 디렉터리 경로를 반환한다.
+// 캐시에 없으면 fill 로 채운다. 반환된 release 를 호출하기 전까지는 삭제되지 않는다.
+func (cache *TestCaseCache) Acquire(problemId int, version string, fill func(dir string) error) (string, func(), error) {
+	key := cacheKey(problemId, version)
+	dir := filepath.Join(cache.root, key)
+
+	cache.mutex.Lock()
+	if element, exists := cache.entries[key]; exists {
+		entry := element.Value.(*testCaseCacheEntry)
+		entry.refs++
+		cache.lru.MoveToFront(element)
+		cache.mutex.Unlock()
+
+		<-entry.ready
+		if entry.err != nil {
+			cache.release(key)
+			return "", nil, entry.err
+		}
+
+		cache.hits.Add(1)
+		metrics.TestCaseCacheLookups.WithLabelValues("hit").Inc()
+		return dir, func() { cache.release(key) }, nil
+	}
+
+	entry := &testCaseCacheEntry{
+		problemId: problemId,
+		version:   version,
+		refs:      1,
+		ready:     make(chan struct{}),
+	}
+	element := cache.lru.PushFront(entry)
+	cache.entries[key] = element
+	cache.mutex.Unlock()
+
+	cache.misses.Add(1)
+	metrics.TestCaseCacheLookups.WithLabelValues("miss").Inc()
+	size, err := cache.fill(dir, fill)
+
+	cache.mutex.Lock()
+	entry.size = size
+	entry.err = err
+	if err != nil {
+		delete(cache.entries, key)
+		cache.lru.Remove(element)
+	} else {
+		cache.size += size
+		cache.evict()
+	}
+	metrics.TestCaseCacheSize.Set(float64(cache.size))
+	cache.mutex.Unlock()
+	close(entry.ready)
+
+	if err != nil {
+		log.Error(err)
+		return "", nil, err
+	}
+
+	return dir, func() { cache.release(key) }, nil
+}
+
+func (cache *TestCaseCache) Stats() TestCaseCacheStats {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	return TestCaseCacheStats{
+		Hits:    cache.hits.Load(),
+		Misses:  cache.misses.Load(),
+		Entries: len(cache.entries),
+		Size:    cache.size,
+	}
+}
+
+func (cache *TestCaseCache) fill(dir string, fill func(dir string) error) (int64, error) {
+	if err := MakeDir(filepath.Dir(dir)); err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	tempDir, err := os.MkdirTemp(filepath.Dir(dir), ".tmp-")
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err = fill(tempDir); err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	size, err := DirSize(tempDir)
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	_ = os.RemoveAll(dir)
+	if err = os.Rename(tempDir, dir); err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return size, nil
+}
+
+func (cache *TestCaseCache) release(key string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if element, exists := cache.entries[key]; exists {
+		element.Value.(*testCaseCacheEntry).refs--
+	}
+	cache.evict()
+}
+
+// evict 는 같은 문제의 이전 버전과, 용량을 넘는 오래된 항목을 지운다. mutex 를 잡은 채로 호출해야 한다.
+func (cache *TestCaseCache) evict() {
+	latest := make(map[int]string)
+	for element := cache.lru.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*testCaseCacheEntry)
+		if _, exists := latest[entry.problemId]; !exists {
+			latest[entry.problemId] = entry.version
+		}
+	}
+
+	for element := cache.lru.Back(); element != nil; {
+		prev := element.Prev()
+		entry := element.Value.(*testCaseCacheEntry)
+		stale := latest[entry.problemId] != entry.version
+		if entry.refs == 0 && (stale || cache.size > cache.maxSize) {
+			cache.remove(element)
+		}
+		element = prev
+	}
+}
+
+func (cache *TestCaseCache) remove(element *list.Element) {
+	entry := element.Value.(*testCaseCacheEntry)
+	key := cacheKey(entry.problemId, entry.version)
+
+	cache.lru.Remove(element)
+	delete(cache.entries, key)
+	cache.size -= entry.size
+	metrics.TestCaseCacheSize.Set(float64(cache.size))
+
+	if err := os.RemoveAll(filepath.Join(cache.root, key)); err != nil {
+		log.Error(err)
+	}
+}
+
+// load 는 서버 재시작 전에 만들어진 캐시 디렉터리를 수정 시각 순서로 다시 등록한다.
+func (cache *TestCaseCache) load() error {
+	problemDirs, err := os.ReadDir(cache.root)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	type loadedEntry struct {
+		entry   *testCaseCacheEntry
+		modTime int64
+	}
+	loaded := make([]loadedEntry, 0)
+
+	for _, problemDir := range problemDirs {
+		problemId, err := strconv.Atoi(problemDir.Name())
+		if err != nil || !problemDir.IsDir() {
+			continue
+		}
+
+		versionDirs, err := os.ReadDir(filepath.Join(cache.root, problemDir.Name()))
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+
+		for _, versionDir := range versionDirs {
+			path := filepath.Join(cache.root, problemDir.Name(), versionDir.Name())
+			if !versionDir.IsDir() || versionDir.Name()[0] == '.' {
+				_ = os.RemoveAll(path)
+				continue
+			}
+
+			info, err := versionDir.Info()
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+
+			size, err := DirSize(path)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+
+			ready := make(chan struct{})
+			close(ready)
+			loaded = append(loaded, loadedEntry{
+				entry: &testCaseCacheEntry{
+					problemId: problemId,
+					version:   versionDir.Name(),
+					size:      size,
+					ready:     ready,
+				},
+				modTime: info.ModTime().UnixNano(),
+			})
+		}
+	}
+
+	sort.Slice(loaded, func(i, j int) bool {
+		return loaded[i].modTime < loaded[j].modTime
+	})
+
+	for _, item := range loaded {
+		key := cacheKey(item.entry.problemId, item.entry.version)
+		cache.entries[key] = cache.lru.PushFront(item.entry)
+		cache.size += item.entry.size
+	}
+
+	cache.evict()
+	metrics.TestCaseCacheSize.Set(float64(cache.size))
+	return nil
+}
+
+func cacheKey(problemId int, version string) string {
+	return filepath.Join(strconv.Itoa(problemId), version)
+}
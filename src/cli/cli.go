@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"leita/src/dataSources"
+	. "leita/src/entities"
+	"leita/src/services"
+)
+
+const usage = `usage: server <command> [arguments]
+
+commands:
+  import-package -problem <problemId> <package.zip>
+        Polygon 문제 패키지로 문제의 제한, 테스트 케이스, 체커, 검증기, 풀이를 가져온다
+  validate -problem <problemId>
+        문제의 검증기로 모든 테스트 입력을 검증한다
+  generate -problem <problemId>
+        생성 스크립트와 main 풀이로 문제의 테스트 케이스를 다시 만든다
+  verify -problem <problemId>
+        문제에 붙은 풀이를 모두 채점해서 태그와 결과가 다른 풀이와 가장 느린 정답 풀이를 보고한다
+`
+
+// verify 가 검증 작업이 끝났는지 확인하는 간격
+const verifyPollInterval = time.Second
+
+// Run 은 서버 대신 관리 명령을 실행한다
+func Run(args []string) error {
+	defer dataSources.CloseDataSource()
+
+	switch args[0] {
+	case "import-package":
+		return importPackage(args[1:])
+	case "validate":
+		return validate(args[1:])
+	case "generate":
+		return generate(args[1:])
+	case "verify":
+		return verify(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+}
+
+func importPackage(args []string) error {
+	flags := flag.NewFlagSet("import-package", flag.ContinueOnError)
+	problemId := flags.Int("problem", 0, "problem id")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *problemId <= 0 || flags.NArg() != 1 {
+		fmt.Fprint(os.Stderr, usage)
+		return errors.New("import-package needs -problem and a package file")
+	}
+
+	archive, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	service, err := services.NewPackageService()
+	if err != nil {
+		return err
+	}
+
+	response, err := service.Import(context.Background(), *problemId, archive)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(response)
+}
+
+func validate(args []string) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	problemId := flags.Int("problem", 0, "problem id")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *problemId <= 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return errors.New("validate needs -problem")
+	}
+
+	service, err := services.NewValidationService()
+	if err != nil {
+		return err
+	}
+
+	response, err := service.Validate(context.Background(), *problemId)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(response)
+}
+
+func generate(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	problemId := flags.Int("problem", 0, "problem id")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *problemId <= 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return errors.New("generate needs -problem")
+	}
+
+	service, err := services.NewGenerationService()
+	if err != nil {
+		return err
+	}
+
+	response, err := service.Generate(context.Background(), *problemId)
+	if err != nil {
+		return err
+	}
+
+	if err = printJSON(response); err != nil {
+		return err
+	}
+	if response.Error != "" {
+		return errors.New(response.Error)
+	}
+
+	return nil
+}
+
+func verify(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	problemId := flags.Int("problem", 0, "problem id")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *problemId <= 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return errors.New("verify needs -problem")
+	}
+
+	service, err := services.NewVerificationService()
+	if err != nil {
+		return err
+	}
+
+	response, err := service.Verify(*problemId)
+	if err != nil {
+		return err
+	}
+
+	for response.Status != VerificationDone.String() {
+		time.Sleep(verifyPollInterval)
+		response, _ = service.GetVerification(response.JobId)
+	}
+
+	if err = printJSON(response); err != nil {
+		return err
+	}
+	if len(response.Mismatches) > 0 {
+		return fmt.Errorf("%d solutions do not match their tags", len(response.Mismatches))
+	}
+
+	return nil
+}
+
+func printJSON(value any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -1,50 +1,158 @@
 package commands
 
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/utils"
+)
+
 type Command struct {
-	BuildCmd  []string
-	RunCmd    []string
-	DeleteCmd []string
+	BuildCmd   []string
+	RunCmd     []string
+	DeleteCmd  []string
+	VersionCmd []string
+	WarmUpRuns int
+	// HelloWorld 는 준비 상태 확인에서 빌드하고 실행해 보는 코드로, HelloWorldOutput 을 출력해야 한다
+	HelloWorld string
 }
 
+const HelloWorldOutput = "Hello, World!"
+
 var Commands = map[string]Command{
 	"C": {
-		BuildCmd:  []string{"gcc", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.c", "-o", "{JUDGE_TYPE}/{SUBMIT_ID}/Main", "-O2", "-Wall", "-lm", "-static", "-std=gnu99"},
-		RunCmd:    []string{"{JUDGE_TYPE}/{SUBMIT_ID}/Main"},
-		DeleteCmd: []string{"rm", "{JUDGE_TYPE}/{SUBMIT_ID}/Main"},
+		BuildCmd:   []string{"gcc", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.c", "-o", "{JUDGE_TYPE}/{SUBMIT_ID}/Main", "-O2", "-Wall", "-lm", "-static", "-std=gnu99"},
+		RunCmd:     []string{"{JUDGE_TYPE}/{SUBMIT_ID}/Main"},
+		DeleteCmd:  []string{"rm", "{JUDGE_TYPE}/{SUBMIT_ID}/Main"},
+		VersionCmd: []string{"gcc", "--version"},
+		HelloWorld: "#include <stdio.h>\nint main(void) { printf(\"Hello, World!\\n\"); return 0; }\n",
 	},
 	"CPP": {
-		BuildCmd:  []string{"g++", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.cpp", "-o", "{JUDGE_TYPE}/{SUBMIT_ID}/Main", "-O2", "-Wall", "-lm", "-static", "-std=gnu++17"},
-		RunCmd:    []string{"{JUDGE_TYPE}/{SUBMIT_ID}/Main"},
-		DeleteCmd: []string{"rm", "{JUDGE_TYPE}/{SUBMIT_ID}/Main"},
+		BuildCmd:   []string{"g++", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.cpp", "-o", "{JUDGE_TYPE}/{SUBMIT_ID}/Main", "-O2", "-Wall", "-lm", "-static", "-std=gnu++17"},
+		RunCmd:     []string{"{JUDGE_TYPE}/{SUBMIT_ID}/Main"},
+		DeleteCmd:  []string{"rm", "{JUDGE_TYPE}/{SUBMIT_ID}/Main"},
+		VersionCmd: []string{"g++", "--version"},
+		HelloWorld: "#include <iostream>\nint main() { std::cout << \"Hello, World!\" << std::endl; return 0; }\n",
 	},
 	"JAVA": {
-		BuildCmd:  []string{"javac", "-J-Xms1024m", "-J-Xmx1920m", "-J-Xss512m", "-encoding UTF-8", "-d", "bin", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.java"},
-		RunCmd:    []string{"java", "-Xms1024m", "-Xmx1920m", "-Xss512m", "-Dfile.encoding=UTF-8", "-XX:+UseSerialGC", "-cp", "bin", "Main"},
-		DeleteCmd: []string{"rm", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.class"},
+		BuildCmd:   []string{"javac", "-J-Xms1024m", "-J-Xmx1920m", "-J-Xss512m", "-encoding UTF-8", "-d", "bin", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.java"},
+		RunCmd:     []string{"java", "-Xms1024m", "-Xmx1920m", "-Xss512m", "-Dfile.encoding=UTF-8", "-XX:+UseSerialGC", "-cp", "bin", "Main"},
+		DeleteCmd:  []string{"rm", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.class"},
+		VersionCmd: []string{"java", "-version"},
+		WarmUpRuns: 1,
+		HelloWorld: "public class Main { public static void main(String[] args) { System.out.println(\"Hello, World!\"); } }\n",
 	},
 	"PYTHON": {
-		BuildCmd:  []string{},
-		RunCmd:    []string{"python3", "-W", "ignore", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.py"},
-		DeleteCmd: []string{},
+		BuildCmd:   []string{},
+		RunCmd:     []string{"python3", "-W", "ignore", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.py"},
+		DeleteCmd:  []string{},
+		VersionCmd: []string{"python3", "--version"},
+		HelloWorld: "print(\"Hello, World!\")\n",
 	},
 	"JAVASCRIPT": {
-		BuildCmd:  []string{},
-		RunCmd:    []string{"node", "--stack-size=65536", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.js"},
-		DeleteCmd: []string{},
+		BuildCmd:   []string{},
+		RunCmd:     []string{"node", "--stack-size=65536", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.js"},
+		DeleteCmd:  []string{},
+		VersionCmd: []string{"node", "--version"},
+		HelloWorld: "console.log(\"Hello, World!\");\n",
 	},
 	"GO": {
-		BuildCmd:  []string{"go", "build", "-o", "{JUDGE_TYPE}/{SUBMIT_ID}/Main", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.go"},
-		RunCmd:    []string{"{JUDGE_TYPE}/{SUBMIT_ID}/Main"},
-		DeleteCmd: []string{"rm", "{JUDGE_TYPE}/{SUBMIT_ID}/Main"},
+		BuildCmd:   []string{"go", "build", "-o", "{JUDGE_TYPE}/{SUBMIT_ID}/Main", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.go"},
+		RunCmd:     []string{"{JUDGE_TYPE}/{SUBMIT_ID}/Main"},
+		DeleteCmd:  []string{"rm", "{JUDGE_TYPE}/{SUBMIT_ID}/Main"},
+		VersionCmd: []string{"go", "version"},
+		HelloWorld: "package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println(\"Hello, World!\") }\n",
 	},
 	"KOTLIN": {
-		BuildCmd:  []string{"kotlinc", "-J-Xms1024m", "-J-Xmx1920m", "-J-Xss512m", "-include-runtime", "-d", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.jar", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.kt"},
-		RunCmd:    []string{"java", "-Xms1024m", "-Xmx1920m", "-Xss512m", "-Dfile.encoding=UTF-8", "-XX:+UseSerialGC", "-jar", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.jar"},
-		DeleteCmd: []string{"rm", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.jar"},
+		BuildCmd:   []string{"kotlinc", "-J-Xms1024m", "-J-Xmx1920m", "-J-Xss512m", "-include-runtime", "-d", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.jar", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.kt"},
+		RunCmd:     []string{"java", "-Xms1024m", "-Xmx1920m", "-Xss512m", "-Dfile.encoding=UTF-8", "-XX:+UseSerialGC", "-jar", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.jar"},
+		DeleteCmd:  []string{"rm", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.jar"},
+		VersionCmd: []string{"kotlinc", "-version"},
+		WarmUpRuns: 1,
+		HelloWorld: "fun main() { println(\"Hello, World!\") }\n",
 	},
 	"SWIFT": {
-		BuildCmd:  []string{"swiftc", "-O", "-o", "{JUDGE_TYPE}/{SUBMIT_ID}/Main", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.swift"},
-		RunCmd:    []string{"{JUDGE_TYPE}/{SUBMIT_ID}/Main"},
-		DeleteCmd: []string{"rm", "{JUDGE_TYPE}/{SUBMIT_ID}/Main"},
+		BuildCmd:   []string{"swiftc", "-O", "-o", "{JUDGE_TYPE}/{SUBMIT_ID}/Main", "{JUDGE_TYPE}/{SUBMIT_ID}/Main.swift"},
+		RunCmd:     []string{"{JUDGE_TYPE}/{SUBMIT_ID}/Main"},
+		DeleteCmd:  []string{"rm", "{JUDGE_TYPE}/{SUBMIT_ID}/Main"},
+		VersionCmd: []string{"swiftc", "--version"},
+		HelloWorld: "print(\"Hello, World!\")\n",
 	},
 }
+
+// WarmUpRuns 는 채점 전에 버릴 실행 횟수를 반환한다. JUDGE_WARMUP_RUNS_{LANGUAGE} 로 덮어쓸 수 있다.
+func WarmUpRuns(language string) int {
+	if value := GetEnv("JUDGE_WARMUP_RUNS_" + language); value != "" {
+		warmUpRuns, err := strconv.Atoi(value)
+		if err == nil && warmUpRuns >= 0 {
+			return warmUpRuns
+		}
+		log.Error("invalid JUDGE_WARMUP_RUNS_", language, ": ", value)
+	}
+
+	return Commands[language].WarmUpRuns
+}
+
+// Languages 는 이 인스턴스가 채점하는 언어를 이름순으로 반환한다.
+// JUDGE_LANGUAGES=CPP,PYTHON 처럼 정할 수 있고, 없으면 Commands 의 모든 언어다
+func Languages() []string {
+	var languages []string
+	if value := GetEnv("JUDGE_LANGUAGES"); value != "" {
+		for _, language := range strings.Split(value, ",") {
+			language = strings.ToUpper(strings.TrimSpace(language))
+			if _, exists := Commands[language]; !exists {
+				log.Error("unknown language in JUDGE_LANGUAGES: ", language)
+				continue
+			}
+			languages = append(languages, language)
+		}
+	} else {
+		for language := range Commands {
+			languages = append(languages, language)
+		}
+	}
+
+	sort.Strings(languages)
+	return languages
+}
+
+// IsSupported 는 language 가 이 인스턴스에서 채점하는 언어인지 확인한다
+func IsSupported(language string) bool {
+	for _, supported := range Languages() {
+		if supported == language {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TimeLimitMultiplier 는 문제의 시간 제한에 곱할 배수를 반환한다. JUDGE_TIME_MULTIPLIER_{LANGUAGE} 로 정할 수 있다.
+func TimeLimitMultiplier(language string) float64 {
+	return limitMultiplier("JUDGE_TIME_MULTIPLIER_" + language)
+}
+
+// MemoryLimitMultiplier 는 문제의 메모리 제한에 곱할 배수를 반환한다. JUDGE_MEMORY_MULTIPLIER_{LANGUAGE} 로 정할 수 있다.
+func MemoryLimitMultiplier(language string) float64 {
+	return limitMultiplier("JUDGE_MEMORY_MULTIPLIER_" + language)
+}
+
+func limitMultiplier(key string) float64 {
+	if value := GetEnv(key); value != "" {
+		multiplier, err := strconv.ParseFloat(value, 64)
+		if err == nil && multiplier > 0 {
+			return multiplier
+		}
+		log.Error("invalid ", key, ": ", value)
+	}
+
+	return 1
+}
+
+// ApplyLimitMultiplier 는 제한에 배수를 곱한 값을 반환한다
+func ApplyLimitMultiplier(limit int, multiplier float64) int {
+	return int(math.Round(float64(limit) * multiplier))
+}
@@ -2,6 +2,7 @@ package dataSources
 
 import (
 	"database/sql"
+	"sync"
 
 	"github.com/gofiber/fiber/v2/log"
 )
@@ -11,6 +12,21 @@ type DataSource struct {
 	objectStorage *ObjectStorage
 }
 
+var (
+	sharedDataSource     *DataSource
+	sharedDataSourceErr  error
+	sharedDataSourceOnce sync.Once
+)
+
+// GetDataSource 는 모든 저장소가 함께 쓰는 DataSource 를 반환한다
+func GetDataSource() (*DataSource, error) {
+	sharedDataSourceOnce.Do(func() {
+		sharedDataSource, sharedDataSourceErr = NewDataSource()
+	})
+
+	return sharedDataSource, sharedDataSourceErr
+}
+
 func NewDataSource() (*DataSource, error) {
 	db, err := NewDatabase()
 	if err != nil {
@@ -37,3 +53,24 @@ func (ds *DataSource) GetDatabase() *sql.DB {
 func (ds *DataSource) GetObjectStorage() *ObjectStorage {
 	return ds.objectStorage
 }
+
+// Close 는 데이터베이스 연결과 오브젝트 스토리지 클라이언트를 닫는다
+func (ds *DataSource) Close() error {
+	ds.objectStorage.Close()
+
+	if err := ds.database.Close(); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// CloseDataSource 는 GetDataSource 로 만든 DataSource 가 있으면 닫는다
+func CloseDataSource() error {
+	if sharedDataSource == nil {
+		return nil
+	}
+
+	return sharedDataSource.Close()
+}
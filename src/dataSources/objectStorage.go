@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"net/http"
 
 	"github.com/gofiber/fiber/v2/log"
 	"github.com/oracle/oci-go-sdk/v65/common"
 	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+	"leita/src/metrics"
 	. "leita/src/utils"
 )
 
@@ -29,28 +31,43 @@ func NewObjectStorage() (*ObjectStorage, error) {
 }
 
 func (os *ObjectStorage) GetObject(objectName string) ([]byte, error) {
-	request := objectstorage.GetObjectRequest{
-		NamespaceName: common.String(GetEnv("OS_NAMESPACE")),
-		BucketName:    common.String(GetEnv("OS_BUCKET")),
-		ObjectName:    common.String(objectName),
+	reader, err := os.GetObjectReader(objectName)
+	if err != nil {
+		log.Error(err)
+		return nil, err
 	}
+	defer reader.Close()
 
-	response, err := os.Client.GetObject(context.Background(), request)
+	content, err := io.ReadAll(reader)
 	if err != nil {
 		log.Error(err)
 		return nil, err
 	}
 
-	content, err := io.ReadAll(response.Content)
+	return content, nil
+}
+
+func (os *ObjectStorage) GetObjectReader(objectName string) (io.ReadCloser, error) {
+	defer metrics.ObserveObjectStorage("get_object")()
+
+	request := objectstorage.GetObjectRequest{
+		NamespaceName: common.String(GetEnv("OS_NAMESPACE")),
+		BucketName:    common.String(GetEnv("OS_BUCKET")),
+		ObjectName:    common.String(objectName),
+	}
+
+	response, err := os.Client.GetObject(context.Background(), request)
 	if err != nil {
 		log.Error(err)
 		return nil, err
 	}
 
-	return content, nil
+	return response.Content, nil
 }
 
 func (os *ObjectStorage) PutObject(objectName string, data []byte) error {
+	defer metrics.ObserveObjectStorage("put_object")()
+
 	request := objectstorage.PutObjectRequest{
 		NamespaceName: common.String(GetEnv("OS_NAMESPACE")),
 		BucketName:    common.String(GetEnv("OS_BUCKET")),
@@ -69,6 +86,8 @@ func (os *ObjectStorage) PutObject(objectName string, data []byte) error {
 }
 
 func (os *ObjectStorage) ListObjects(folderPath string) ([]objectstorage.ObjectSummary, error) {
+	defer metrics.ObserveObjectStorage("list_objects")()
+
 	request := objectstorage.ListObjectsRequest{
 		NamespaceName: common.String(GetEnv("OS_NAMESPACE")),
 		BucketName:    common.String(GetEnv("OS_BUCKET")),
@@ -83,3 +102,33 @@ func (os *ObjectStorage) ListObjects(folderPath string) ([]objectstorage.ObjectS
 
 	return response.ListObjects.Objects, nil
 }
+
+// IsNotFound 는 없는 오브젝트를 요청해서 난 에러인지 확인한다
+func IsNotFound(err error) bool {
+	serviceError, ok := common.IsServiceError(err)
+	return ok && serviceError.GetHTTPStatusCode() == http.StatusNotFound
+}
+
+// Ping 은 버킷에 접근할 수 있는지 확인한다
+func (os *ObjectStorage) Ping(ctx context.Context) error {
+	defer metrics.ObserveObjectStorage("head_bucket")()
+
+	request := objectstorage.HeadBucketRequest{
+		NamespaceName: common.String(GetEnv("OS_NAMESPACE")),
+		BucketName:    common.String(GetEnv("OS_BUCKET")),
+	}
+
+	if _, err := os.Client.HeadBucket(ctx, request); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// Close 는 유휴 연결을 닫는다. OCI 클라이언트는 따로 닫을 자원이 없다
+func (os *ObjectStorage) Close() {
+	if client, ok := os.Client.HTTPClient.(*http.Client); ok {
+		client.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,75 @@
+package dataSources
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/redis/go-redis/v9"
+	. "leita/src/utils"
+)
+
+var (
+	sharedRedis     *redis.Client
+	sharedRedisErr  error
+	sharedRedisOnce sync.Once
+)
+
+// GetRedis 는 모든 저장소가 함께 쓰는 Redis 클라이언트를 반환한다
+func GetRedis() (*redis.Client, error) {
+	sharedRedisOnce.Do(func() {
+		sharedRedis, sharedRedisErr = NewRedis()
+	})
+
+	return sharedRedis, sharedRedisErr
+}
+
+func NewRedis() (*redis.Client, error) {
+	addr := GetEnv("REDIS_ADDR")
+	if addr == "" {
+		err := fmt.Errorf("invalid redis configuration")
+		log.Error(err)
+		return nil, err
+	}
+
+	db := 0
+	if value := GetEnv("REDIS_DB"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			err = fmt.Errorf("invalid REDIS_DB: %s", value)
+			log.Error(err)
+			return nil, err
+		}
+		db = parsed
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: GetEnv("REDIS_PASSWORD"),
+		DB:       db,
+	})
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		_ = client.Close()
+		log.Error(err)
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// CloseRedis 는 GetRedis 로 만든 클라이언트가 있으면 닫는다
+func CloseRedis() error {
+	if sharedRedis == nil {
+		return nil
+	}
+
+	if err := sharedRedis.Close(); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package entities
+
+import "time"
+
+type ApiKey struct {
+	Id        string    `json:"id"`
+	Secret    string    `json:"secret"`
+	Scopes    []string  `json:"scopes"`
+	NotBefore time.Time `json:"notBefore"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type AuthErrorResponse struct {
+	Error string `json:"error"`
+}
+
+type QuotaErrorResponse struct {
+	Error string `json:"error"`
+}
+
+type ApiKeyScopeEnum int
+
+const (
+	ScopeSubmit ApiKeyScopeEnum = iota
+	ScopeRun
+	ScopeAdmin
+)
+
+func (as ApiKeyScopeEnum) String() string {
+	return map[ApiKeyScopeEnum]string{
+		ScopeSubmit: "submit",
+		ScopeRun:    "run",
+		ScopeAdmin:  "admin",
+	}[as]
+}
+
+// HasScope 는 admin 범위를 가진 키는 모든 범위를 가진 것으로 본다
+func (key ApiKey) HasScope(scope ApiKeyScopeEnum) bool {
+	for _, s := range key.Scopes {
+		if s == scope.String() || s == ScopeAdmin.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func (key ApiKey) IsActive(now time.Time) bool {
+	if !key.NotBefore.IsZero() && now.Before(key.NotBefore) {
+		return false
+	}
+	if !key.ExpiresAt.IsZero() && !now.Before(key.ExpiresAt) {
+		return false
+	}
+	return true
+}
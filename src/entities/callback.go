@@ -0,0 +1,25 @@
+package entities
+
+import "time"
+
+type CallbackPayload struct {
+	SubmitId  int    `json:"submitId"`
+	ProblemId int    `json:"problemId"`
+	Language  string `json:"language"`
+	Result    string `json:"result"`
+	Error     string `json:"error"`
+	// Retryable 은 채점을 끝내지 못해 Result 가 최종 결과가 아니고, 다시 제출해야 함을 뜻한다
+	Retryable  bool      `json:"retryable"`
+	UsedTime   int64     `json:"usedTime"`
+	UsedMemory int64     `json:"usedMemory"`
+	JudgedAt   time.Time `json:"judgedAt"`
+}
+
+type SaveDeadLetterDTO struct {
+	DeliveryId  string
+	SubmitId    int
+	CallbackUrl string
+	Payload     []byte
+	Attempts    int
+	LastError   string
+}
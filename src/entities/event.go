@@ -0,0 +1,33 @@
+package entities
+
+type JudgeEvent struct {
+	Type        string `json:"type"`
+	SubmitId    int    `json:"submitId"`
+	TestCase    int    `json:"testCase"`
+	TestCaseNum int    `json:"testCaseNum"`
+	Result      string `json:"result"`
+	Error       string `json:"error"`
+	Output      string `json:"output"`
+	UsedTime    int64  `json:"usedTime"`
+	UsedMemory  int64  `json:"usedMemory"`
+}
+
+type JudgeEventEnum int
+
+const (
+	JudgeEventCompileStart JudgeEventEnum = iota
+	JudgeEventCompileFinish
+	JudgeEventTestCase
+	JudgeEventResult
+	JudgeEventError
+)
+
+func (je JudgeEventEnum) String() string {
+	return map[JudgeEventEnum]string{
+		JudgeEventCompileStart:  "COMPILE_START",
+		JudgeEventCompileFinish: "COMPILE_FINISH",
+		JudgeEventTestCase:      "TEST_CASE",
+		JudgeEventResult:        "RESULT",
+		JudgeEventError:         "ERROR",
+	}[je]
+}
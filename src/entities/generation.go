@@ -0,0 +1,53 @@
+package entities
+
+// SetGenerationRequest 의 code 는 base64 로 보낸다
+type SetGenerationRequest struct {
+	Generators []GeneratorRequest `json:"generators"`
+	// 한 줄에 테스트 하나, "<generator> [args...]" 형식. # 로 시작하는 줄은 주석이다
+	Script   string         `json:"script"`
+	Solution *SourceRequest `json:"solution"`
+}
+
+type GeneratorRequest struct {
+	Name     string `json:"name"`
+	Language string `json:"language"`
+	Code     string `json:"code"`
+}
+
+type SourceRequest struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+}
+
+type GenerateTestsResponse struct {
+	ProblemId   int                 `json:"problemId"`
+	TestCaseNum int                 `json:"testCaseNum"`
+	Tests       []GeneratedTest     `json:"tests"`
+	Validation  *ValidationResponse `json:"validation"`
+	Error       string              `json:"error"`
+}
+
+type GeneratedTest struct {
+	Test       int    `json:"test"`
+	Command    string `json:"command"`
+	InputSize  int64  `json:"inputSize"`
+	OutputSize int64  `json:"outputSize"`
+	Error      string `json:"error"`
+}
+
+type SetGenerationDTO struct {
+	ProblemId  int
+	Generators []GeneratorSource
+	Script     string
+	Solution   *SourceCode
+}
+
+type GeneratorSource struct {
+	Name string
+	SourceCode
+}
+
+type SourceCode struct {
+	Language string
+	Code     []byte
+}
@@ -0,0 +1,52 @@
+package entities
+
+// HackRequest 의 input 은 base64 로 보낸다
+type HackRequest struct {
+	SubmitId   int    `json:"submitId"`
+	Input      string `json:"input"`
+	AddToTests bool   `json:"addToTests"`
+}
+
+// 출력은 앞부분만 담는다
+type HackResponse struct {
+	SubmitId  int    `json:"submitId"`
+	ProblemId int    `json:"problemId"`
+	Status    string `json:"status"`
+	// 대상 제출이 해킹 입력에서 받은 결과
+	Result         string `json:"result"`
+	UsedTime       int64  `json:"usedTime"`
+	UsedMemory     int64  `json:"usedMemory"`
+	Expected       string `json:"expected"`
+	Output         string `json:"output"`
+	ValidatorError string `json:"validatorError"`
+	// 테스트 케이스로 붙였으면 붙인 뒤의 테스트 케이스 수, 아니면 0
+	TestCaseNum int    `json:"testCaseNum"`
+	Error       string `json:"error"`
+}
+
+type HackDTO struct {
+	SubmitId   int
+	Input      []byte
+	AddToTests bool
+}
+
+type HackStatusEnum int
+
+const (
+	// 대상 제출이 해킹 입력에서 틀렸다
+	HackSuccessful HackStatusEnum = iota
+	HackUnsuccessful
+	// 검증기가 해킹 입력을 거절했다
+	HackInvalidInput
+	// 검증기나 main 풀이를 빌드하거나 실행하지 못했다
+	HackFailed
+)
+
+func (hs HackStatusEnum) String() string {
+	return map[HackStatusEnum]string{
+		HackSuccessful:   "SUCCESSFUL",
+		HackUnsuccessful: "UNSUCCESSFUL",
+		HackInvalidInput: "INVALID_INPUT",
+		HackFailed:       "FAILED",
+	}[hs]
+}
@@ -0,0 +1,32 @@
+package entities
+
+import "time"
+
+type HealthResponse struct {
+	Status string        `json:"status"`
+	Checks []HealthCheck `json:"checks"`
+}
+
+type HealthCheck struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error"`
+	Latency   int64     `json:"latency"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+type HealthStatusEnum int
+
+const (
+	HealthUp HealthStatusEnum = iota
+	HealthDown
+	HealthUnknown
+)
+
+func (hs HealthStatusEnum) String() string {
+	return map[HealthStatusEnum]string{
+		HealthUp:      "UP",
+		HealthDown:    "DOWN",
+		HealthUnknown: "UNKNOWN",
+	}[hs]
+}
@@ -0,0 +1,14 @@
+package entities
+
+type LanguageResponse struct {
+	Language              string   `json:"language"`
+	Extension             string   `json:"extension"`
+	Available             bool     `json:"available"`
+	Version               string   `json:"version"`
+	Error                 string   `json:"error"`
+	BuildCmd              []string `json:"buildCmd"`
+	RunCmd                []string `json:"runCmd"`
+	TimeLimitMultiplier   float64  `json:"timeLimitMultiplier"`
+	MemoryLimitMultiplier float64  `json:"memoryLimitMultiplier"`
+	WarmUpRuns            int      `json:"warmUpRuns"`
+}
@@ -0,0 +1,20 @@
+package entities
+
+import "time"
+
+type RegisterNodeRequest struct {
+	Url string `json:"url"`
+}
+
+type NodeResponse struct {
+	Url       string    `json:"url"`
+	Healthy   bool      `json:"healthy"`
+	Languages []string  `json:"languages"`
+	InFlight  int64     `json:"inFlight"`
+	CheckedAt time.Time `json:"checkedAt"`
+	Error     string    `json:"error"`
+}
+
+type GatewayErrorResponse struct {
+	Error string `json:"error"`
+}
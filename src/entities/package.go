@@ -0,0 +1,74 @@
+package entities
+
+import "time"
+
+type ImportPackageResponse struct {
+	ProblemId   int                 `json:"problemId"`
+	ShortName   string              `json:"shortName"`
+	Names       map[string]string   `json:"names"`
+	TimeLimit   int                 `json:"timeLimit"`
+	MemoryLimit int                 `json:"memoryLimit"`
+	TestCaseNum int                 `json:"testCaseNum"`
+	Checker     *PackageFile        `json:"checker"`
+	Validators  []PackageFile       `json:"validators"`
+	Solutions   []PackageSolution   `json:"solutions"`
+	Validation  *ValidationResponse `json:"validation"`
+	Warnings    []string            `json:"warnings"`
+	Error       string              `json:"error"`
+}
+
+// ProblemManifest 는 가져온 패키지의 내용을 problems/{problemId}/manifest.json 으로 남긴 것이다.
+// 파일 경로와 생성 스크립트(Script)는 오브젝트 스토리지의 이름이고, 생성 스크립트는 생성기를 Name 으로 부른다
+type ProblemManifest struct {
+	ProblemId   int                `json:"problemId"`
+	ShortName   string             `json:"shortName"`
+	Names       map[string]string  `json:"names"`
+	TimeLimit   int                `json:"timeLimit"`
+	MemoryLimit int                `json:"memoryLimit"`
+	TestCaseNum int                `json:"testCaseNum"`
+	Samples     []int              `json:"samples"`
+	Checker     *PackageFile       `json:"checker"`
+	Validators  []PackageFile      `json:"validators"`
+	Solutions   []PackageSolution  `json:"solutions"`
+	Statements  []PackageStatement `json:"statements"`
+	Resources   []PackageFile      `json:"resources"`
+	Generators  []PackageFile      `json:"generators"`
+	Script      string             `json:"script"`
+	ImportedAt  time.Time          `json:"importedAt"`
+}
+
+type PackageFile struct {
+	Path     string `json:"path"`
+	Type     string `json:"type"`
+	Language string `json:"language"`
+	Name     string `json:"name,omitempty"`
+}
+
+// 풀이 태그는 Polygon 과 같다. main 태그가 붙은 풀이로 생성한 테스트의 정답을 만든다
+const MainSolutionTag = "main"
+
+type PackageSolution struct {
+	PackageFile
+	Tag string `json:"tag"`
+}
+
+type PackageStatement struct {
+	Path     string `json:"path"`
+	Type     string `json:"type"`
+	Language string `json:"language"`
+}
+
+type ImportProblemDTO struct {
+	ProblemId   int
+	TimeLimit   int
+	MemoryLimit int
+	Checker     string
+	TestCases   []PackageTestCase
+	// 오브젝트 스토리지 이름과 내용
+	Objects map[string][]byte
+}
+
+type PackageTestCase struct {
+	Input  []byte
+	Output []byte
+}
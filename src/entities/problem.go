@@ -1,9 +1,10 @@
 package entities
 
 type SubmitProblemRequest struct {
-	SubmitId int    `json:"submitId"`
-	Language string `json:"language"`
-	Code     string `json:"code"`
+	SubmitId    int    `json:"submitId"`
+	Language    string `json:"language"`
+	Code        string `json:"code"`
+	CallbackUrl string `json:"callbackUrl"`
 }
 
 type SubmitProblemResponse struct {
@@ -14,13 +15,16 @@ type SubmitProblemResponse struct {
 }
 
 type SubmitProblemDTO struct {
-	ProblemId int
-	SubmitId  int
-	Language  string
-	Code      []byte
-	BuildCmd  []string
-	RunCmd    []string
-	DeleteCmd []string
+	ProblemId  int
+	SubmitId   int
+	Language   string
+	Code       []byte
+	BuildCmd   []string
+	RunCmd     []string
+	DeleteCmd  []string
+	WarmUpRuns int
+	// 문제 검증처럼 제출 기록이 없는 채점은 코드를 남기지 않는다
+	SkipSaveCode bool
 }
 
 type SaveSubmitResultDTO struct {
@@ -37,9 +41,10 @@ type RunProblemRequest struct {
 }
 
 type RunProblemResponse struct {
-	Result string `json:"result"`
-	Error  string `json:"error"`
-	Output string `json:"output"`
+	Result          string `json:"result"`
+	Error           string `json:"error"`
+	Output          string `json:"output"`
+	OutputTruncated bool   `json:"outputTruncated"`
 }
 
 type TestCase struct {
@@ -59,9 +64,10 @@ type RunProblemDTO struct {
 }
 
 type RunProblemResult struct {
-	Result JudgeResultEnum
-	Error  error
-	Output string
+	Result          JudgeResultEnum
+	Error           error
+	Output          string
+	OutputTruncated bool
 }
 
 type JudgeResultEnum int
@@ -88,7 +94,55 @@ func (jr JudgeResultEnum) String() string {
 	}[jr]
 }
 
+// ParseJudgeResult 는 String 으로 만든 값을 되돌린다. 모르는 값이면 JudgeUnknown 을 반환한다
+func ParseJudgeResult(result string) JudgeResultEnum {
+	for jr := JudgeCorrect; jr <= JudgeTimeOut; jr++ {
+		if jr.String() == result {
+			return jr
+		}
+	}
+	return JudgeUnknown
+}
+
+// CheckerEnum 은 출력을 정답과 비교하는 방법이다
+type CheckerEnum int
+
+const (
+	// 출력 끝의 공백만 무시하고 나머지는 그대로 비교한다
+	CheckerExact CheckerEnum = iota
+	// testlib 의 std::wcmp.cpp 처럼 공백으로 나눈 토큰을 차례로 비교한다
+	CheckerTokens
+	// testlib 의 std::lcmp.cpp 처럼 줄마다 공백으로 나눈 토큰을 비교한다
+	CheckerLines
+)
+
+func (c CheckerEnum) String() string {
+	return map[CheckerEnum]string{
+		CheckerExact:  "EXACT",
+		CheckerTokens: "TOKENS",
+		CheckerLines:  "LINES",
+	}[c]
+}
+
+// ParseChecker 는 String 으로 만든 값을 되돌린다. 모르는 값이면 CheckerExact 를 반환한다
+func ParseChecker(checker string) CheckerEnum {
+	for c := CheckerExact; c <= CheckerLines; c++ {
+		if c.String() == checker {
+			return c
+		}
+	}
+	return CheckerExact
+}
+
 type GetProblemInfoDAO struct {
-	TimeLimit   int
+	TimeLimit int
+	// MB 단위
 	MemoryLimit int
+	// 검증한 적이 없으면 NONE
+	ValidationStatus string
+	// 마지막으로 검증한 테스트 케이스 버전과 지금 버전
+	ValidationVersion string
+	TestcasesVersion  string
+	// 패키지로 가져오지 않은 문제는 EXACT
+	Checker string
 }
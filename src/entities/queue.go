@@ -0,0 +1,55 @@
+package entities
+
+type JudgeJobResponse struct {
+	JobId      int64  `json:"jobId"`
+	SubmitId   int    `json:"submitId"`
+	ProblemId  int    `json:"problemId"`
+	Language   string `json:"language"`
+	Status     string `json:"status"`
+	Attempts   int    `json:"attempts"`
+	Result     string `json:"result"`
+	UsedTime   int64  `json:"usedTime"`
+	UsedMemory int64  `json:"usedMemory"`
+	Error      string `json:"error"`
+}
+
+type JudgeJob struct {
+	Id          int64
+	SubmitId    int
+	ProblemId   int
+	Language    string
+	Code        []byte
+	CallbackUrl string
+	Status      string
+	Attempts    int
+	WorkerId    string
+	Result      string
+	UsedTime    int64
+	UsedMemory  int64
+	Error       string
+}
+
+type CompleteJudgeJobDTO struct {
+	Result     string
+	UsedTime   int64
+	UsedMemory int64
+	Error      string
+}
+
+type JudgeJobStatusEnum int
+
+const (
+	JudgeJobQueued JudgeJobStatusEnum = iota
+	JudgeJobRunning
+	JudgeJobDone
+	JudgeJobFailed
+)
+
+func (js JudgeJobStatusEnum) String() string {
+	return map[JudgeJobStatusEnum]string{
+		JudgeJobQueued:  "QUEUED",
+		JudgeJobRunning: "RUNNING",
+		JudgeJobDone:    "DONE",
+		JudgeJobFailed:  "FAILED",
+	}[js]
+}
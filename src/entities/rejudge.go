@@ -0,0 +1,68 @@
+package entities
+
+import "time"
+
+type RejudgeRequest struct {
+	ProblemId int       `json:"problemId"`
+	Results   []string  `json:"results"`
+	Languages []string  `json:"languages"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+}
+
+type RejudgeResponse struct {
+	JobId     string          `json:"jobId"`
+	DryRun    bool            `json:"dryRun"`
+	Status    string          `json:"status"`
+	Total     int             `json:"total"`
+	Done      int             `json:"done"`
+	Changes   []RejudgeChange `json:"changes"`
+	Unchanged int             `json:"unchanged"`
+	Failed    []RejudgeError  `json:"failed"`
+	Error     string          `json:"error"`
+}
+
+type RejudgeChange struct {
+	SubmitId   int    `json:"submitId"`
+	Before     string `json:"before"`
+	After      string `json:"after"`
+	UsedTime   int64  `json:"usedTime"`
+	UsedMemory int64  `json:"usedMemory"`
+}
+
+type RejudgeError struct {
+	SubmitId int    `json:"submitId"`
+	Error    string `json:"error"`
+}
+
+type GetSubmitsDTO struct {
+	SubmitId  int
+	ProblemId int
+	Results   []string
+	Languages []string
+	From      time.Time
+	To        time.Time
+}
+
+type GetSubmitDAO struct {
+	SubmitId   int
+	ProblemId  int
+	Language   string
+	Result     string
+	UsedTime   int64
+	UsedMemory int64
+}
+
+type RejudgeStatusEnum int
+
+const (
+	RejudgeRunning RejudgeStatusEnum = iota
+	RejudgeDone
+)
+
+func (rs RejudgeStatusEnum) String() string {
+	return map[RejudgeStatusEnum]string{
+		RejudgeRunning: "RUNNING",
+		RejudgeDone:    "DONE",
+	}[rs]
+}
@@ -0,0 +1,30 @@
+package entities
+
+type JudgeSocketRequest struct {
+	RequestId string     `json:"requestId"`
+	Type      string     `json:"type"`
+	ProblemId int        `json:"problemId"`
+	SubmitId  int        `json:"submitId"`
+	Language  string     `json:"language"`
+	Code      string     `json:"code"`
+	TestCases []TestCase `json:"testCases"`
+}
+
+type JudgeSocketResponse struct {
+	RequestId string `json:"requestId"`
+	JudgeEvent
+}
+
+type JudgeSocketRequestEnum int
+
+const (
+	JudgeSocketSubmit JudgeSocketRequestEnum = iota
+	JudgeSocketRun
+)
+
+func (js JudgeSocketRequestEnum) String() string {
+	return map[JudgeSocketRequestEnum]string{
+		JudgeSocketSubmit: "SUBMIT",
+		JudgeSocketRun:    "RUN",
+	}[js]
+}
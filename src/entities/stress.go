@@ -0,0 +1,61 @@
+package entities
+
+// StressTestRequest 의 code 는 base64 로 보낸다. 생성기는 "<seed> <size>" 두 인자를 받아 입력 하나를 출력해야 한다
+type StressTestRequest struct {
+	Candidate SourceRequest `json:"candidate"`
+	Brute     SourceRequest `json:"brute"`
+	Generator SourceRequest `json:"generator"`
+	// 전체 실행 시간 예산(ms)
+	TimeBudget int `json:"timeBudget"`
+	// 프로그램 한 번의 시간 제한(ms)
+	TimeLimit int `json:"timeLimit"`
+	// 생성기에 넘기는 size 의 최댓값
+	MaxSize int `json:"maxSize"`
+}
+
+type StressTestResponse struct {
+	Status         string                `json:"status"`
+	Iterations     int                   `json:"iterations"`
+	Elapsed        int64                 `json:"elapsed"`
+	Counterexample *StressCounterexample `json:"counterexample"`
+	Error          string                `json:"error"`
+}
+
+// 입력과 출력은 앞부분만 담는다
+type StressCounterexample struct {
+	Seed     int    `json:"seed"`
+	Size     int    `json:"size"`
+	Input    string `json:"input"`
+	Expected string `json:"expected"`
+	Output   string `json:"output"`
+	Result   string `json:"result"`
+	Error    string `json:"error"`
+}
+
+type StressTestDTO struct {
+	Candidate  SourceCode
+	Brute      SourceCode
+	Generator  SourceCode
+	TimeBudget int
+	TimeLimit  int
+	MaxSize    int
+}
+
+type StressStatusEnum int
+
+const (
+	// 시간 예산 안에 다른 출력을 찾지 못했다
+	StressPassed StressStatusEnum = iota
+	// 후보 풀이가 틀리거나 실패하는 입력을 찾았다
+	StressFound
+	// 프로그램을 빌드하지 못했거나 생성기나 완전 탐색 풀이가 실패했다
+	StressFailed
+)
+
+func (ss StressStatusEnum) String() string {
+	return map[StressStatusEnum]string{
+		StressPassed: "PASSED",
+		StressFound:  "FOUND",
+		StressFailed: "FAILED",
+	}[ss]
+}
@@ -0,0 +1,22 @@
+package entities
+
+import "time"
+
+type SubmissionDAO struct {
+	CodeDigest string
+	Result     string
+	UsedTime   int64
+	UsedMemory int64
+	Error      string
+	Finished   bool
+	FinishedAt time.Time
+}
+
+type SaveSubmissionResultDTO struct {
+	SubmitId   int
+	CodeDigest string
+	Result     string
+	UsedTime   int64
+	UsedMemory int64
+	Error      string
+}
@@ -0,0 +1,45 @@
+package entities
+
+import "time"
+
+type SetValidatorRequest struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+}
+
+type ValidationResponse struct {
+	ProblemId int    `json:"problemId"`
+	Status    string `json:"status"`
+	// 검증한 테스트 케이스 버전
+	DataVersion string           `json:"dataVersion"`
+	Validator   *PackageFile     `json:"validator"`
+	Tests       []TestValidation `json:"tests"`
+	ValidatedAt time.Time        `json:"validatedAt"`
+	Error       string           `json:"error"`
+}
+
+type TestValidation struct {
+	Test  int    `json:"test"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error"`
+}
+
+type ValidationStatusEnum int
+
+const (
+	ValidationValid ValidationStatusEnum = iota
+	// 검증기가 거절한 테스트가 있다, 이 상태인 문제는 채점하지 않는다
+	ValidationInvalid
+	// 검증기를 빌드하거나 실행하지 못했다
+	ValidationError
+	ValidationNone
+)
+
+func (vs ValidationStatusEnum) String() string {
+	return map[ValidationStatusEnum]string{
+		ValidationValid:   "VALID",
+		ValidationInvalid: "INVALID",
+		ValidationError:   "ERROR",
+		ValidationNone:    "NONE",
+	}[vs]
+}
@@ -0,0 +1,61 @@
+package entities
+
+// AddSolutionRequest 의 tag 는 Polygon 의 풀이 태그(main, accepted, wrong-answer, time-limit-exceeded ...)이고 code 는 base64 로 보낸다
+type AddSolutionRequest struct {
+	Name     string `json:"name"`
+	Tag      string `json:"tag"`
+	Language string `json:"language"`
+	Code     string `json:"code"`
+}
+
+type VerificationResponse struct {
+	JobId     string `json:"jobId"`
+	ProblemId int    `json:"problemId"`
+	Status    string `json:"status"`
+	Total     int    `json:"total"`
+	Done      int    `json:"done"`
+	// 문제의 시간 제한, 언어별 배수는 적용하지 않은 값이다
+	TimeLimit      int                    `json:"timeLimit"`
+	Solutions      []SolutionVerification `json:"solutions"`
+	Mismatches     []SolutionVerification `json:"mismatches"`
+	SlowestCorrect *SolutionVerification  `json:"slowestCorrect"`
+	Warnings       []string               `json:"warnings"`
+	Error          string                 `json:"error"`
+}
+
+type SolutionVerification struct {
+	Name     string   `json:"name"`
+	Path     string   `json:"path"`
+	Language string   `json:"language"`
+	Tag      string   `json:"tag"`
+	Expected []string `json:"expected"`
+	Result   string   `json:"result"`
+	// 언어별 배수를 적용한 시간 제한
+	TimeLimit  int    `json:"timeLimit"`
+	UsedTime   int64  `json:"usedTime"`
+	UsedMemory int64  `json:"usedMemory"`
+	Matches    bool   `json:"matches"`
+	Skipped    bool   `json:"skipped"`
+	Error      string `json:"error"`
+}
+
+type AddSolutionDTO struct {
+	ProblemId int
+	Name      string
+	Tag       string
+	SourceCode
+}
+
+type VerificationStatusEnum int
+
+const (
+	VerificationRunning VerificationStatusEnum = iota
+	VerificationDone
+)
+
+func (vs VerificationStatusEnum) String() string {
+	return map[VerificationStatusEnum]string{
+		VerificationRunning: "RUNNING",
+		VerificationDone:    "DONE",
+	}[vs]
+}
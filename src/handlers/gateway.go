@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/gofiber/fiber/v2/middleware/proxy"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	. "leita/src/entities"
+	"leita/src/services"
+	. "leita/src/utils"
+)
+
+// 채점은 오래 걸릴 수 있으므로 노드 응답을 넉넉하게 기다린다
+const defaultNodeRequestTimeout = 5 * time.Minute
+
+var ErrNotRoutable = errors.New("this endpoint is not available in router mode, send it to a judge node directly")
+
+// 라우터가 받은 인증 정보는 노드에 넘기지 않고 JUDGE_NODE_API_KEY 로 바꿔서 보낸다
+var credentialHeaders = []string{
+	fiber.HeaderAuthorization,
+	"X-Api-Key",
+	"X-Api-Key-Id",
+	"X-Timestamp",
+	"X-Signature",
+}
+
+type GatewayHandler struct {
+	registry *services.NodeRegistry
+	client   *fasthttp.Client
+	timeout  time.Duration
+}
+
+func NewGatewayHandler() (*GatewayHandler, error) {
+	registry, err := services.NewNodeRegistry()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	timeout := defaultNodeRequestTimeout
+	if value := GetEnv("JUDGE_NODE_TIMEOUT"); value != "" {
+		timeout, err = time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			err = fmt.Errorf("invalid JUDGE_NODE_TIMEOUT: %s", value)
+			log.Error(err)
+			return nil, err
+		}
+	}
+
+	return &GatewayHandler{
+		registry: registry,
+		client: &fasthttp.Client{
+			NoDefaultUserAgentHeader: true,
+			DisablePathNormalizing:   true,
+		},
+		timeout: timeout,
+	}, nil
+}
+
+// ReadinessProbe 는 정상인 노드가 하나라도 있으면 준비된 것으로 본다
+func (handler *GatewayHandler) ReadinessProbe() func(*fiber.Ctx) bool {
+	return func(c *fiber.Ctx) bool {
+		return handler.registry.HasHealthyNode()
+	}
+}
+
+// SubmitProblem 은 제출을 언어를 지원하는 노드로 보낸다
+func (handler *GatewayHandler) SubmitProblem() fiber.Handler {
+	return handler.forward(func(c *fiber.Ctx, status int, err error) error {
+		return c.Status(status).JSON(SubmitProblemResponse{
+			Result: JudgeUnknown.String(),
+			Error:  err.Error(),
+		})
+	})
+}
+
+// RunProblem 은 실행을 언어를 지원하는 노드로 보낸다
+func (handler *GatewayHandler) RunProblem() fiber.Handler {
+	return handler.forward(func(c *fiber.Ctx, status int, err error) error {
+		return c.Status(status).JSON([]RunProblemResponse{
+			{
+				Result: JudgeUnknown.String(),
+				Error:  err.Error(),
+			},
+		})
+	})
+}
+
+func (handler *GatewayHandler) forward(respondError func(c *fiber.Ctx, status int, err error) error) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req struct {
+			Language string `json:"language"`
+		}
+		if err := json.Unmarshal(c.Body(), &req); err != nil {
+			log.Error(err)
+			return respondError(c, fiber.StatusBadRequest, err)
+		}
+
+		if err := handler.proxy(c, req.Language); err != nil {
+			log.Error(err)
+			switch {
+			case errors.Is(err, services.ErrUnsupportedLanguage):
+				return respondError(c, fiber.StatusBadRequest, err)
+			case errors.Is(err, services.ErrNoHealthyNode):
+				return respondError(c, fiber.StatusServiceUnavailable, err)
+			case errors.Is(err, fasthttp.ErrTimeout):
+				return respondError(c, fiber.StatusGatewayTimeout, err)
+			default:
+				return respondError(c, fiber.StatusBadGateway, err)
+			}
+		}
+
+		return nil
+	}
+}
+
+// 노드에 연결하지 못했거나 노드가 종료 중(503)이면 다른 노드로 다시 보낸다.
+// 시간 초과는 노드가 아직 채점 중일 수 있으므로 다시 보내지 않는다
+func (handler *GatewayHandler) proxy(c *fiber.Ctx, language string) error {
+	for _, header := range credentialHeaders {
+		c.Request().Header.Del(header)
+	}
+	if apiKey := handler.registry.ApiKey(); apiKey != "" {
+		c.Request().Header.Set("X-Api-Key", apiKey)
+	}
+
+	carrier := propagation.HeaderCarrier{}
+	otel.GetTextMapPropagator().Inject(c.UserContext(), carrier)
+	for key := range carrier {
+		c.Request().Header.Set(key, carrier.Get(key))
+	}
+
+	tried := make(map[string]bool)
+	for {
+		nodeUrl, release, err := handler.registry.Pick(language, tried)
+		if err != nil {
+			return err
+		}
+		tried[nodeUrl] = true
+
+		err = proxy.DoTimeout(c, nodeUrl+c.OriginalURL(), handler.timeout, handler.client)
+		release()
+
+		switch {
+		case errors.Is(err, fasthttp.ErrTimeout):
+			return err
+		case err != nil:
+			handler.registry.MarkUnhealthy(nodeUrl, err)
+		case c.Response().StatusCode() == fiber.StatusServiceUnavailable:
+			handler.registry.MarkUnhealthy(nodeUrl, errors.New("node is draining"))
+		default:
+			return nil
+		}
+
+		log.Warn("다른 채점 노드로 다시 보냅니다: ", language)
+	}
+}
+
+// GetLanguages 는 정상인 노드가 지원하는 언어를 모아서 반환한다
+func (handler *GatewayHandler) GetLanguages() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusOK).JSON(handler.registry.Languages())
+	}
+}
+
+// NotRoutable 은 채점 이벤트 구독, WebSocket, 재채점, 큐, 스트레스 테스트, 핵, 문제 관리처럼
+// 특정 노드의 상태나 데이터베이스가 필요한 경로를 노드로 보내지 않고 501 과 ErrNotRoutable 로 거절한다
+func (handler *GatewayHandler) NotRoutable() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusNotImplemented).JSON(GatewayErrorResponse{
+			Error: ErrNotRoutable.Error(),
+		})
+	}
+}
+
+// GetNodes godoc
+//
+//	@Description	라우터 모드에서 등록된 채점 노드와 상태, 지원 언어를 반환한다.
+//	@Produce		json
+//	@Tags			Node
+//	@Success		200	{array}	NodeResponse
+//	@Router			/nodes [get]
+func (handler *GatewayHandler) GetNodes() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusOK).JSON(handler.registry.Nodes())
+	}
+}
+
+// RegisterNode godoc
+//
+//	@Description	라우터 모드에서 채점 노드를 등록한다. 이미 등록된 노드면 상태를 다시 확인한다.
+//	@Accept			json
+//	@Produce		json
+//	@Tags			Node
+//	@Param			requestBody	body		RegisterNodeRequest	true	"requestBody"
+//	@Success		200			{object}	NodeResponse
+//	@Failure		400			{object}	NodeResponse
+//	@Router			/nodes [post]
+func (handler *GatewayHandler) RegisterNode() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req RegisterNodeRequest
+		if err := c.BodyParser(&req); err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(NodeResponse{
+				Error: err.Error(),
+			})
+		}
+
+		node, err := handler.registry.Register(req.Url)
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(NodeResponse{
+				Url:   req.Url,
+				Error: err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(node)
+	}
+}
+
+// UnregisterNode godoc
+//
+//	@Description	라우터 모드에서 채점 노드를 삭제한다.
+//	@Accept			json
+//	@Tags			Node
+//	@Param			requestBody	body	RegisterNodeRequest	true	"requestBody"
+//	@Success		204
+//	@Failure		404
+//	@Router			/nodes [delete]
+func (handler *GatewayHandler) UnregisterNode() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req RegisterNodeRequest
+		if err := c.BodyParser(&req); err != nil {
+			log.Error(err)
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+
+		if !handler.registry.Unregister(req.Url) {
+			return c.SendStatus(fiber.StatusNotFound)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/commands"
+	. "leita/src/entities"
+	"leita/src/services"
+	. "leita/src/utils"
+)
+
+type GenerationHandler struct {
+	service *services.GenerationService
+}
+
+func NewGenerationHandler() (*GenerationHandler, error) {
+	service, err := services.NewGenerationService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &GenerationHandler{
+		service: service,
+	}, nil
+}
+
+// SetGeneration godoc
+//
+//	@Description	문제의 생성기와 생성 스크립트를 등록하고, solution 이 있으면 main 풀이로 등록한다. code 는 base64 로 보낸다.
+//	@Description	생성 스크립트는 한 줄에 테스트 하나를 "<generator> [args...]" 로 적고, 테스트는 /generate 를 호출해야 만든다.
+//	@Accept			json
+//	@Produce		json
+//	@Tags			Generation
+//	@Param			problemId	path	string					true	"problemId"
+//	@Param			requestBody	body	SetGenerationRequest	true	"requestBody"
+//	@Success		204
+//	@Failure		400	{object}	GenerateTestsResponse
+//	@Failure		500	{object}	GenerateTestsResponse
+//	@Router			/problem/{problemId}/generation [put]
+func (handler *GenerationHandler) SetGeneration() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		problemId, err := strconv.Atoi(c.Params("problemId"))
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(GenerateTestsResponse{
+				Error: err.Error(),
+			})
+		}
+
+		var req SetGenerationRequest
+		if err := c.BodyParser(&req); err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(GenerateTestsResponse{
+				ProblemId: problemId,
+				Error:     err.Error(),
+			})
+		}
+
+		dto := SetGenerationDTO{
+			ProblemId:  problemId,
+			Generators: make([]GeneratorSource, 0, len(req.Generators)),
+			Script:     req.Script,
+		}
+
+		languages := make([]string, 0, len(req.Generators)+1)
+		for _, generator := range req.Generators {
+			languages = append(languages, generator.Language)
+			dto.Generators = append(dto.Generators, GeneratorSource{
+				Name: generator.Name,
+				SourceCode: SourceCode{
+					Language: generator.Language,
+					Code:     DecodeBase64([]byte(generator.Code)),
+				},
+			})
+		}
+		if req.Solution != nil {
+			languages = append(languages, req.Solution.Language)
+			dto.Solution = &SourceCode{
+				Language: req.Solution.Language,
+				Code:     DecodeBase64([]byte(req.Solution.Code)),
+			}
+		}
+
+		for _, language := range languages {
+			if !IsSupported(language) {
+				err := errUnsupportedLanguage(language)
+				log.Error(err)
+				return c.Status(fiber.StatusBadRequest).JSON(GenerateTestsResponse{
+					ProblemId: problemId,
+					Error:     err.Error(),
+				})
+			}
+		}
+
+		err = handler.service.SetGeneration(dto)
+		if errors.Is(err, services.ErrInvalidGeneration) {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(GenerateTestsResponse{
+				ProblemId: problemId,
+				Error:     err.Error(),
+			})
+		}
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusInternalServerError).JSON(GenerateTestsResponse{
+				ProblemId: problemId,
+				Error:     err.Error(),
+			})
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// Generate godoc
+//
+//	@Description	생성 스크립트대로 테스트 입력을 만들고 main 풀이로 정답을 만든 뒤, 문제의 테스트 케이스를 모두 바꾸고 다시 검증한다.
+//	@Description	생성기나 main 풀이가 실패하면 테스트 케이스는 그대로 두고 422 를 반환한다.
+//	@Produce		json
+//	@Tags			Generation
+//	@Param			problemId	path		string	true	"problemId"
+//	@Success		200			{object}	GenerateTestsResponse
+//	@Failure		422			{object}	GenerateTestsResponse
+//	@Failure		500			{object}	GenerateTestsResponse
+//	@Router			/problem/{problemId}/generate [post]
+func (handler *GenerationHandler) Generate() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		problemId, err := strconv.Atoi(c.Params("problemId"))
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(GenerateTestsResponse{
+				Error: err.Error(),
+			})
+		}
+
+		response, err := handler.service.Generate(c.UserContext(), problemId)
+		if errors.Is(err, services.ErrDraining) {
+			log.Error(err)
+			return c.Status(fiber.StatusServiceUnavailable).JSON(GenerateTestsResponse{
+				ProblemId: problemId,
+				Error:     err.Error(),
+			})
+		}
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusInternalServerError).JSON(GenerateTestsResponse{
+				ProblemId: problemId,
+				Error:     err.Error(),
+			})
+		}
+		if response.Error != "" {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(response)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(response)
+	}
+}
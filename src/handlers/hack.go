@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/entities"
+	"leita/src/middlewares"
+	"leita/src/services"
+	. "leita/src/utils"
+)
+
+type HackHandler struct {
+	service *services.HackService
+}
+
+func NewHackHandler() (*HackHandler, error) {
+	service, err := services.NewHackService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &HackHandler{
+		service: service,
+	}, nil
+}
+
+// Hack godoc
+//
+//	@Description	submitId 제출을 겨냥한 입력을 문제의 검증기로 검증한 뒤, main 풀이와 대상 제출을 실행해서 해킹이 성공했는지 반환한다.
+//	@Description	addToTests 가 켜져 있고 해킹이 성공하면 입력과 main 풀이의 출력을 문제의 테스트 케이스로 붙인다. input 은 base64 로 보낸다.
+//	@Description	테스트 케이스를 바꾸므로 addToTests 는 admin 범위를 가진 키만 쓸 수 있다.
+//	@Accept			json
+//	@Produce		json
+//	@Tags			Problem
+//	@Param			requestBody	body		HackRequest	true	"requestBody"
+//	@Success		200			{object}	HackResponse
+//	@Failure		400			{object}	HackResponse
+//	@Failure		403			{object}	HackResponse
+//	@Failure		404			{object}	HackResponse
+//	@Failure		409			{object}	HackResponse
+//	@Failure		422			{object}	HackResponse
+//	@Failure		429			{object}	QuotaErrorResponse
+//	@Failure		500			{object}	HackResponse
+//	@Router			/problem/hack [post]
+func (handler *HackHandler) Hack() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req HackRequest
+		if err := c.BodyParser(&req); err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(HackResponse{
+				Error: err.Error(),
+			})
+		}
+
+		// 인증이 꺼져 있으면 키가 없으므로 막지 않는다
+		key, authenticated := c.Locals(middlewares.ApiKeyLocal).(ApiKey)
+		if req.AddToTests && authenticated && !key.HasScope(ScopeAdmin) {
+			return c.Status(fiber.StatusForbidden).JSON(HackResponse{
+				SubmitId: req.SubmitId,
+				Error:    errForbiddenScope.Error(),
+			})
+		}
+
+		dto := HackDTO{
+			SubmitId:   req.SubmitId,
+			Input:      DecodeBase64([]byte(req.Input)),
+			AddToTests: req.AddToTests,
+		}
+
+		response, err := handler.service.Hack(c.UserContext(), dto)
+		if err != nil {
+			log.Error(err)
+			return c.Status(hackErrorStatus(err)).JSON(HackResponse{
+				SubmitId: req.SubmitId,
+				Error:    err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(response)
+	}
+}
+
+func hackErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidHack):
+		return fiber.StatusBadRequest
+	case errors.Is(err, services.ErrHackTargetNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrInvalidTestData):
+		return fiber.StatusConflict
+	case errors.Is(err, services.ErrHackNotSupported):
+		return fiber.StatusUnprocessableEntity
+	case errors.Is(err, services.ErrDraining):
+		return fiber.StatusServiceUnavailable
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/entities"
+	"leita/src/services"
+)
+
+type HealthHandler struct {
+	service *services.HealthService
+}
+
+func NewHealthHandler() (*HealthHandler, error) {
+	service, err := services.NewHealthService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &HealthHandler{
+		service: service,
+	}, nil
+}
+
+// ReadinessProbe 는 healthcheck 미들웨어의 /readyz 에서 쓴다
+func (handler *HealthHandler) ReadinessProbe() func(*fiber.Ctx) bool {
+	return func(c *fiber.Ctx) bool {
+		return handler.service.Ready(c.UserContext())
+	}
+}
+
+// Readiness 는 확인 항목별 결과를 보여 준다. 준비되지 않았으면 503 을 반환한다
+func (handler *HealthHandler) Readiness() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		response := handler.service.Check(c.UserContext())
+		if response.Status != HealthUp.String() {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(response)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(response)
+	}
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"leita/src/services"
+)
+
+type LanguageHandler struct {
+	service *services.LanguageService
+}
+
+func NewLanguageHandler() (*LanguageHandler, error) {
+	service, err := services.NewLanguageService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &LanguageHandler{
+		service: service,
+	}, nil
+}
+
+// GetLanguages godoc
+//
+//	@Description	이 인스턴스가 채점하는 언어와 설치된 컴파일러, 런타임 버전, 빌드/실행 명령, 제한 배수를 반환한다.
+//	@Produce		json
+//	@Tags			Language
+//	@Success		200	{array}	entities.LanguageResponse
+//	@Router			/languages [get]
+func (handler *LanguageHandler) GetLanguages() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		responses := handler.service.GetLanguages()
+		return c.Status(fiber.StatusOK).JSON(responses)
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/entities"
+	"leita/src/services"
+)
+
+type PackageHandler struct {
+	service *services.PackageService
+}
+
+func NewPackageHandler() (*PackageHandler, error) {
+	service, err := services.NewPackageService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &PackageHandler{
+		service: service,
+	}, nil
+}
+
+// ImportPackage godoc
+//
+//	@Description	Polygon 문제 패키지(full 패키지 zip)로 문제의 시간/메모리 제한과 테스트 케이스를 바꾼다.
+//	@Description	체커, 검증기, 풀이, 지문은 오브젝트 스토리지의 problems/{problemId}/ 에 올리고, 검증기가 있으면 테스트 입력을 검증한다.
+//	@Description	zip 을 본문으로 보내거나 multipart 의 package 필드로 보낸다. 큰 패키지는 JUDGE_BODY_LIMIT 를 늘리거나 import-package 명령을 쓴다.
+//	@Accept			application/zip
+//	@Accept			mpfd
+//	@Produce		json
+//	@Tags			Package
+//	@Param			problemId	path		string	true	"problemId"
+//	@Param			package		formData	file	false	"package"
+//	@Success		200			{object}	ImportPackageResponse
+//	@Failure		400			{object}	ImportPackageResponse
+//	@Router			/problem/{problemId}/package [post]
+func (handler *PackageHandler) ImportPackage() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		problemId, err := strconv.Atoi(c.Params("problemId"))
+		if err != nil || problemId <= 0 {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(ImportPackageResponse{
+				Error: "invalid problemId: " + c.Params("problemId"),
+			})
+		}
+
+		archive, err := packageArchive(c)
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(ImportPackageResponse{
+				ProblemId: problemId,
+				Error:     err.Error(),
+			})
+		}
+
+		response, err := handler.service.Import(c.UserContext(), problemId, archive)
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(ImportPackageResponse{
+				ProblemId: problemId,
+				Error:     err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(response)
+	}
+}
+
+func packageArchive(c *fiber.Ctx) ([]byte, error) {
+	if !c.Is("multipart") {
+		return c.Body(), nil
+	}
+
+	fileHeader, err := c.FormFile("package")
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
@@ -1,19 +1,32 @@
 package handlers
 
 import (
-	"math"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
+	"github.com/valyala/fasthttp"
 	. "leita/src/commands"
 	. "leita/src/entities"
+	"leita/src/middlewares"
 	"leita/src/services"
 	. "leita/src/utils"
 )
 
+func errUnsupportedLanguage(language string) error {
+	return fmt.Errorf("unsupported language: %s", language)
+}
+
 type ProblemHandler struct {
-	service *services.ProblemService
+	service         *services.ProblemService
+	callbackService *services.CallbackService
+	runQuota        *middlewares.RunQuota
 }
 
 func NewProblemHandler() (*ProblemHandler, error) {
@@ -23,21 +36,40 @@ func NewProblemHandler() (*ProblemHandler, error) {
 		return nil, err
 	}
 
+	callbackService, err := services.NewCallbackService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	runQuota, err := middlewares.GetRunQuota()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
 	return &ProblemHandler{
-		service: service,
+		service:         service,
+		callbackService: callbackService,
+		runQuota:        runQuota,
 	}, nil
 }
 
 // SubmitProblem godoc
 //
-//	@Accept		json
-//	@Produce	json
-//	@Tags		Problem
-//	@Param		problemId	path		string					true	"problemId"
-//	@Param		requestBody	body		SubmitProblemRequest	true	"requestBody"
-//	@Success	200			{object}	SubmitProblemResponse
-//	@Failure	500			{object}	SubmitProblemResponse
-//	@Router		/problem/submit/{problemId} [post]
+//	@Description	같은 submitId 로 다시 요청하면 진행 중인 채점의 결과나 남겨둔 결과를 반환하고, 코드가 다르면 409 를 반환한다.
+//	@Description	테스트 데이터 검증 결과가 INVALID 인 문제는 채점하지 않고 409 를 반환한다.
+//	@Description	callbackUrl 이 있으면 바로 202 를 반환하고, 채점이 끝나면 결과를 callbackUrl 로 POST 한다.
+//	@Accept			json
+//	@Produce		json
+//	@Tags			Problem
+//	@Param			problemId	path		string					true	"problemId"
+//	@Param			requestBody	body		SubmitProblemRequest	true	"requestBody"
+//	@Success		200			{object}	SubmitProblemResponse
+//	@Success		202
+//	@Failure		409			{object}	SubmitProblemResponse
+//	@Failure		500			{object}	SubmitProblemResponse
+//	@Router			/problem/submit/{problemId} [post]
 func (handler *ProblemHandler) SubmitProblem() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var req SubmitProblemRequest
@@ -48,26 +80,41 @@ func (handler *ProblemHandler) SubmitProblem() fiber.Handler {
 			})
 		}
 
+		if !IsSupported(req.Language) {
+			err := errUnsupportedLanguage(req.Language)
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(SubmitProblemResponse{
+				Result: JudgeUnknown.String(),
+				Error:  err.Error(),
+			})
+		}
+
 		problemId, _ := strconv.Atoi(c.Params("problemId"))
 		submitId := req.SubmitId
 		language := req.Language
 		code := DecodeBase64([]byte(req.Code))
-		command := Commands[language]
-		buildCmd := ReplaceCommand(command.BuildCmd, "submit", submitId)
-		runCmd := ReplaceCommand(command.RunCmd, "submit", submitId)
-		deleteCmd := ReplaceCommand(command.DeleteCmd, "submit", submitId)
-
-		submitProblemDTO := SubmitProblemDTO{
-			ProblemId: problemId,
-			SubmitId:  submitId,
-			Language:  language,
-			Code:      code,
-			BuildCmd:  buildCmd,
-			RunCmd:    runCmd,
-			DeleteCmd: deleteCmd,
+
+		submitProblemDTO := services.NewSubmitProblemDTO(problemId, submitId, language, code)
+
+		if req.CallbackUrl != "" {
+			return handler.submitWithCallback(c, submitProblemDTO, req.CallbackUrl)
 		}
 
-		result, usedTime, usedMemory, err := handler.service.SubmitProblem(submitProblemDTO)
+		result, usedTime, usedMemory, err := handler.service.SubmitProblem(c.UserContext(), submitProblemDTO)
+		if errors.Is(err, services.ErrDraining) {
+			log.Error(err)
+			return c.Status(fiber.StatusServiceUnavailable).JSON(SubmitProblemResponse{
+				Result: JudgeUnknown.String(),
+				Error:  err.Error(),
+			})
+		}
+		if errors.Is(err, services.ErrSubmissionConflict) || errors.Is(err, services.ErrInvalidTestData) {
+			log.Error(err)
+			return c.Status(fiber.StatusConflict).JSON(SubmitProblemResponse{
+				Result: JudgeUnknown.String(),
+				Error:  err.Error(),
+			})
+		}
 		if result == JudgeUnknown {
 			log.Error(err)
 			return c.Status(fiber.StatusInternalServerError).JSON(SubmitProblemResponse{
@@ -85,6 +132,107 @@ func (handler *ProblemHandler) SubmitProblem() fiber.Handler {
 	}
 }
 
+// 응답을 기다리지 않도록 바로 202 를 보내고, 채점 결과는 콜백으로 전달한다
+func (handler *ProblemHandler) submitWithCallback(c *fiber.Ctx, dto SubmitProblemDTO, callbackUrl string) error {
+	if err := handler.callbackService.Validate(callbackUrl); err != nil {
+		log.Error(err)
+		return c.Status(fiber.StatusBadRequest).JSON(SubmitProblemResponse{
+			Result: JudgeUnknown.String(),
+			Error:  err.Error(),
+		})
+	}
+
+	err := handler.service.CheckSubmission(dto)
+	if errors.Is(err, services.ErrSubmissionConflict) {
+		log.Error(err)
+		return c.Status(fiber.StatusConflict).JSON(SubmitProblemResponse{
+			Result: JudgeUnknown.String(),
+			Error:  err.Error(),
+		})
+	}
+	if err != nil {
+		log.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(SubmitProblemResponse{
+			Result: JudgeUnknown.String(),
+			Error:  err.Error(),
+		})
+	}
+
+	if services.IsDraining() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(SubmitProblemResponse{
+			Result: JudgeUnknown.String(),
+			Error:  services.ErrDraining.Error(),
+		})
+	}
+
+	// 요청이 끝난 뒤에도 trace context 는 이어서 쓴다
+	ctx := context.WithoutCancel(c.UserContext())
+	handler.callbackService.Deliver(services.SubmitDeliveryId(dto.SubmitId), callbackUrl, func() CallbackPayload {
+		result, usedTime, usedMemory, err := handler.service.SubmitProblem(ctx, dto)
+		return services.NewCallbackPayload(dto, result, usedTime, usedMemory, err)
+	})
+
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+// SubmitEvents godoc
+//
+//	@Description	채점 진행 상황을 Server-Sent Events 로 보낸다. 결과 이벤트를 보낸 뒤 연결을 닫는다.
+//	@Produce		text/event-stream
+//	@Tags			Problem
+//	@Param			submitId	path		string	true	"submitId"
+//	@Success		200			{object}	JudgeEvent
+//	@Router			/problem/submit/{submitId}/events [get]
+func (handler *ProblemHandler) SubmitEvents() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		submitId, err := strconv.Atoi(c.Params("submitId"))
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(SubmitProblemResponse{
+				Error: err.Error(),
+			})
+		}
+
+		events, unsubscribe := services.GetJudgeEventBroker().Subscribe("submit", submitId)
+
+		c.Set(fiber.HeaderContentType, "text/event-stream")
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		c.Set(fiber.HeaderConnection, "keep-alive")
+		c.Set("X-Accel-Buffering", "no")
+
+		c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+			defer unsubscribe()
+
+			heartbeat := time.NewTicker(15 * time.Second)
+			defer heartbeat.Stop()
+
+			for {
+				select {
+				case event, ok := <-events:
+					if !ok {
+						return
+					}
+
+					data, err := json.Marshal(event)
+					if err != nil {
+						log.Error(err)
+						return
+					}
+					_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+				case <-heartbeat.C:
+					_, _ = fmt.Fprint(w, ": heartbeat\n\n")
+				}
+
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}))
+
+		return nil
+	}
+}
+
 // RunProblem godoc
 //
 //	@Accept		json
@@ -93,6 +241,8 @@ func (handler *ProblemHandler) SubmitProblem() fiber.Handler {
 //	@Param		problemId	path		string				true	"problemId"
 //	@Param		requestBody	body		RunProblemRequest	true	"requestBody"
 //	@Success	200			{object}	[]RunProblemResponse
+//	@Failure	413			{object}	[]RunProblemResponse
+//	@Failure	429			{object}	QuotaErrorResponse
 //	@Failure	500			{object}	[]RunProblemResponse
 //	@Router		/problem/run/{problemId} [post]
 func (handler *ProblemHandler) RunProblem() fiber.Handler {
@@ -107,35 +257,51 @@ func (handler *ProblemHandler) RunProblem() fiber.Handler {
 			})
 		}
 
+		if err := handler.runQuota.CheckTestCases(req.TestCases); err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON([]RunProblemResponse{
+				{
+					Error: err.Error(),
+				},
+			})
+		}
+
+		if !IsSupported(req.Language) {
+			err := errUnsupportedLanguage(req.Language)
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON([]RunProblemResponse{
+				{
+					Result: JudgeUnknown.String(),
+					Error:  err.Error(),
+				},
+			})
+		}
+
 		problemId, _ := strconv.Atoi(c.Params("problemId"))
 		language := req.Language
 		code := DecodeBase64([]byte(req.Code))
 		testCases := req.TestCases
-		submitId := RandomInt(int(math.Pow10(11)), int(math.Pow10(12)-1))
-		command := Commands[language]
-		buildCmd := ReplaceCommand(command.BuildCmd, "run", submitId)
-		runCmd := ReplaceCommand(command.RunCmd, "run", submitId)
-		deleteCmd := ReplaceCommand(command.DeleteCmd, "run", submitId)
-
-		runProblemDTO := RunProblemDTO{
-			ProblemId: problemId,
-			SubmitId:  submitId,
-			Language:  language,
-			Code:      code,
-			TestCases: testCases,
-			BuildCmd:  buildCmd,
-			RunCmd:    runCmd,
-			DeleteCmd: deleteCmd,
-		}
 
-		results := handler.service.RunProblem(runProblemDTO)
+		runProblemDTO := services.NewRunProblemDTO(problemId, language, code, testCases)
+
+		results := handler.service.RunProblem(c.UserContext(), runProblemDTO)
+		if len(results) == 1 && errors.Is(results[0].Error, services.ErrDraining) {
+			log.Error(results[0].Error)
+			return c.Status(fiber.StatusServiceUnavailable).JSON([]RunProblemResponse{
+				{
+					Result: JudgeUnknown.String(),
+					Error:  results[0].Error.Error(),
+				},
+			})
+		}
 
 		responses := make([]RunProblemResponse, 0, len(results))
 		for _, result := range results {
 			responses = append(responses, RunProblemResponse{
-				Result: result.Result.String(),
-				Error: ErrStrIfNotNil(result.Error),
-				Output: result.Output,
+				Result:          result.Result.String(),
+				Error:           ErrStrIfNotNil(result.Error),
+				Output:          result.Output,
+				OutputTruncated: result.OutputTruncated,
 			})
 		}
 
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/commands"
+	. "leita/src/entities"
+	"leita/src/services"
+	. "leita/src/utils"
+)
+
+type QueueHandler struct {
+	service         *services.QueueService
+	callbackService *services.CallbackService
+}
+
+func NewQueueHandler() (*QueueHandler, error) {
+	service, err := services.NewQueueService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	callbackService, err := services.NewCallbackService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &QueueHandler{
+		service:         service,
+		callbackService: callbackService,
+	}, nil
+}
+
+// EnqueueSubmit godoc
+//
+//	@Description	제출을 채점 큐에 넣고 바로 작업 상태를 반환한다. 채점은 JUDGE_MODE=worker 인 워커가 하고, 결과는 submit 테이블에 저장되고 callbackUrl 이 있으면 그곳으로 POST 한다.
+//	@Accept			json
+//	@Produce		json
+//	@Tags			Queue
+//	@Param			problemId	path		string					true	"problemId"
+//	@Param			requestBody	body		SubmitProblemRequest	true	"requestBody"
+//	@Success		202			{object}	JudgeJobResponse
+//	@Failure		400			{object}	JudgeJobResponse
+//	@Failure		500			{object}	JudgeJobResponse
+//	@Router			/queue/submit/{problemId} [post]
+func (handler *QueueHandler) EnqueueSubmit() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req SubmitProblemRequest
+		if err := c.BodyParser(&req); err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(JudgeJobResponse{
+				Error: err.Error(),
+			})
+		}
+
+		// 이 인스턴스가 아니라 워커가 채점하므로 알려진 언어인지만 확인한다
+		if _, exists := Commands[req.Language]; !exists {
+			err := errUnsupportedLanguage(req.Language)
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(JudgeJobResponse{
+				Error: err.Error(),
+			})
+		}
+
+		// 콜백은 워커가 보내지만, 잘못된 URL 은 큐에 넣기 전에 거른다
+		if req.CallbackUrl != "" {
+			if err := handler.callbackService.Validate(req.CallbackUrl); err != nil {
+				log.Error(err)
+				return c.Status(fiber.StatusBadRequest).JSON(JudgeJobResponse{
+					Error: err.Error(),
+				})
+			}
+		}
+
+		problemId, err := strconv.Atoi(c.Params("problemId"))
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(JudgeJobResponse{
+				Error: err.Error(),
+			})
+		}
+
+		code := DecodeBase64([]byte(req.Code))
+
+		response, err := handler.service.Enqueue(problemId, req.SubmitId, req.Language, code, req.CallbackUrl)
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusInternalServerError).JSON(JudgeJobResponse{
+				Error: err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusAccepted).JSON(response)
+	}
+}
+
+// GetJob godoc
+//
+//	@Description	채점 큐에 넣은 작업의 상태와 결과를 반환한다.
+//	@Produce		json
+//	@Tags			Queue
+//	@Param			jobId	path		string	true	"jobId"
+//	@Success		200		{object}	JudgeJobResponse
+//	@Failure		404		{object}	JudgeJobResponse
+//	@Router			/queue/{jobId} [get]
+func (handler *QueueHandler) GetJob() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		jobId, err := strconv.ParseInt(c.Params("jobId"), 10, 64)
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(JudgeJobResponse{
+				Error: err.Error(),
+			})
+		}
+
+		response, exists, err := handler.service.GetJob(jobId)
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusInternalServerError).JSON(JudgeJobResponse{
+				Error: err.Error(),
+			})
+		}
+		if !exists {
+			return c.Status(fiber.StatusNotFound).JSON(JudgeJobResponse{
+				JobId: jobId,
+				Error: "job not found",
+			})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(response)
+	}
+}
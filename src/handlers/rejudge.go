@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/entities"
+	"leita/src/services"
+)
+
+type RejudgeHandler struct {
+	service *services.RejudgeService
+}
+
+func NewRejudgeHandler() (*RejudgeHandler, error) {
+	service, err := services.NewRejudgeService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &RejudgeHandler{
+		service: service,
+	}, nil
+}
+
+// RejudgeSubmit godoc
+//
+//	@Produce	json
+//	@Tags		Rejudge
+//	@Param		submitId	path		string	true	"submitId"
+//	@Success	202			{object}	RejudgeResponse
+//	@Failure	400			{object}	RejudgeResponse
+//	@Failure	500			{object}	RejudgeResponse
+//	@Router		/rejudge/submit/{submitId} [post]
+func (handler *RejudgeHandler) RejudgeSubmit() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		submitId, err := strconv.Atoi(c.Params("submitId"))
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(RejudgeResponse{
+				Error: err.Error(),
+			})
+		}
+
+		return handler.rejudge(c, GetSubmitsDTO{SubmitId: submitId})
+	}
+}
+
+// RejudgeProblem godoc
+//
+//	@Produce	json
+//	@Tags		Rejudge
+//	@Param		problemId	path		string	true	"problemId"
+//	@Success	202			{object}	RejudgeResponse
+//	@Failure	400			{object}	RejudgeResponse
+//	@Failure	500			{object}	RejudgeResponse
+//	@Router		/rejudge/problem/{problemId} [post]
+func (handler *RejudgeHandler) RejudgeProblem() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		problemId, err := strconv.Atoi(c.Params("problemId"))
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(RejudgeResponse{
+				Error: err.Error(),
+			})
+		}
+
+		return handler.rejudge(c, GetSubmitsDTO{ProblemId: problemId})
+	}
+}
+
+// RejudgeSubmits godoc
+//
+//	@Accept		json
+//	@Produce	json
+//	@Tags		Rejudge
+//	@Param		requestBody	body		RejudgeRequest	true	"requestBody"
+//	@Success	202			{object}	RejudgeResponse
+//	@Failure	400			{object}	RejudgeResponse
+//	@Failure	500			{object}	RejudgeResponse
+//	@Router		/rejudge [post]
+func (handler *RejudgeHandler) RejudgeSubmits() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req RejudgeRequest
+		if err := c.BodyParser(&req); err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(RejudgeResponse{
+				Error: err.Error(),
+			})
+		}
+
+		return handler.rejudge(c, GetSubmitsDTO{
+			ProblemId: req.ProblemId,
+			Results:   req.Results,
+			Languages: req.Languages,
+			From:      req.From,
+			To:        req.To,
+		})
+	}
+}
+
+// GetRejudge godoc
+//
+//	@Produce	json
+//	@Tags		Rejudge
+//	@Param		jobId	path		string	true	"jobId"
+//	@Success	200		{object}	RejudgeResponse
+//	@Failure	404		{object}	RejudgeResponse
+//	@Router		/rejudge/{jobId} [get]
+func (handler *RejudgeHandler) GetRejudge() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		jobId := c.Params("jobId")
+
+		response, exists := handler.service.GetRejudge(jobId)
+		if !exists {
+			return c.Status(fiber.StatusNotFound).JSON(RejudgeResponse{
+				JobId: jobId,
+				Error: "rejudge job not found",
+			})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(response)
+	}
+}
+
+func (handler *RejudgeHandler) rejudge(c *fiber.Ctx, dto GetSubmitsDTO) error {
+	response, err := handler.service.Rejudge(dto)
+	if errors.Is(err, services.ErrEmptyRejudgeFilter) {
+		log.Error(err)
+		return c.Status(fiber.StatusBadRequest).JSON(RejudgeResponse{
+			Error: err.Error(),
+		})
+	}
+	if err != nil {
+		log.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(RejudgeResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(response)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/commands"
+	. "leita/src/entities"
+	"leita/src/middlewares"
+	"leita/src/services"
+	. "leita/src/utils"
+)
+
+var errForbiddenScope = errors.New("api key does not have required scope")
+
+// JudgeSocket godoc
+//
+//	@Description	하나의 WebSocket 연결로 제출(SUBMIT)과 실행(RUN)을 요청한다.
+//	@Description	요청마다 JudgeSocketRequest 를 보내면, 같은 requestId 로 컴파일, 테스트 케이스, 결과 이벤트가 차례로 돌아온다.
+//	@Tags			Problem
+//	@Param			requestBody	body		JudgeSocketRequest	true	"requestBody"
+//	@Success		101			{object}	JudgeSocketResponse
+//	@Router			/problem/ws [get]
+func (handler *ProblemHandler) JudgeSocket() fiber.Handler {
+	return websocket.New(func(conn *websocket.Conn) {
+		var writeMutex sync.Mutex
+		write := func(response JudgeSocketResponse) {
+			writeMutex.Lock()
+			defer writeMutex.Unlock()
+
+			if err := conn.WriteJSON(response); err != nil {
+				log.Error(err)
+			}
+		}
+
+		apiKey := conn.Locals(middlewares.ApiKeyLocal)
+		userKey, _ := conn.Locals(middlewares.UserKeyLocal).(string)
+		keyId, _ := conn.Locals(middlewares.KeyIdLocal).(string)
+
+		var wg sync.WaitGroup
+		defer wg.Wait()
+
+		for {
+			var req JudgeSocketRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					log.Error(err)
+				}
+				return
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() {
+					if r := recover(); r != nil {
+						err := fmt.Errorf("panic while handling request: %v", r)
+						log.Error(err, "\n", string(debug.Stack()))
+						write(JudgeSocketResponse{
+							RequestId:  req.RequestId,
+							JudgeEvent: JudgeEvent{Type: JudgeEventError.String(), Error: err.Error()},
+						})
+					}
+				}()
+
+				if err := handler.judgeSocketRequest(req, apiKey, keyId, userKey, write); err != nil {
+					log.Error(err)
+					write(JudgeSocketResponse{
+						RequestId:  req.RequestId,
+						JudgeEvent: JudgeEvent{Type: JudgeEventError.String(), Error: err.Error()},
+					})
+				}
+			}()
+		}
+	})
+}
+
+// apiKey 는 인증이 꺼져 있으면 nil 이다.
+// 연결할 때만이 아니라 요청마다 요청 빈도를 확인한다
+func (handler *ProblemHandler) judgeSocketRequest(req JudgeSocketRequest, apiKey any, keyId, userKey string, write func(JudgeSocketResponse)) error {
+	if err := handler.runQuota.AllowMessage(keyId, userKey); err != nil {
+		return err
+	}
+
+	if !IsSupported(req.Language) {
+		return errUnsupportedLanguage(req.Language)
+	}
+
+	code := DecodeBase64([]byte(req.Code))
+	broker := services.GetJudgeEventBroker()
+	key, authenticated := apiKey.(ApiKey)
+
+	var events <-chan JudgeEvent
+	var unsubscribe func()
+
+	switch req.Type {
+	case JudgeSocketSubmit.String():
+		if authenticated && !key.HasScope(ScopeSubmit) {
+			return errForbiddenScope
+		}
+		dto := services.NewSubmitProblemDTO(req.ProblemId, req.SubmitId, req.Language, code)
+		if err := handler.service.CheckSubmission(dto); err != nil {
+			return err
+		}
+		events, unsubscribe = broker.Subscribe("submit", dto.SubmitId)
+		go func() {
+			defer services.RecoverJudge("submit", dto.SubmitId)
+			handler.service.SubmitProblem(context.Background(), dto)
+		}()
+	case JudgeSocketRun.String():
+		if authenticated && !key.HasScope(ScopeRun) {
+			return errForbiddenScope
+		}
+		if err := handler.runQuota.CheckTestCases(req.TestCases); err != nil {
+			return err
+		}
+		if err := handler.runQuota.Acquire(userKey); err != nil {
+			return err
+		}
+		defer handler.runQuota.Release(userKey)
+
+		dto := services.NewRunProblemDTO(req.ProblemId, req.Language, code, req.TestCases)
+		events, unsubscribe = broker.Subscribe("run", dto.SubmitId)
+		go func() {
+			defer services.RecoverJudge("run", dto.SubmitId)
+			handler.service.RunProblem(context.Background(), dto)
+		}()
+	default:
+		return errors.New("unknown request type: " + req.Type)
+	}
+	defer unsubscribe()
+
+	for event := range events {
+		write(JudgeSocketResponse{
+			RequestId:  req.RequestId,
+			JudgeEvent: event,
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/commands"
+	. "leita/src/entities"
+	"leita/src/services"
+	. "leita/src/utils"
+)
+
+type StressHandler struct {
+	service *services.StressService
+}
+
+func NewStressHandler() (*StressHandler, error) {
+	service, err := services.NewStressService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &StressHandler{
+		service: service,
+	}, nil
+}
+
+// StressTest godoc
+//
+//	@Description	생성기로 만든 입력마다 후보 풀이와 완전 탐색 풀이의 출력을 비교해서, 다른 출력을 찾거나 시간 예산을 다 쓸 때까지 실행한다.
+//	@Description	생성기는 "<seed> <size>" 인자를 받고 size 는 1 부터 maxSize 까지 늘어난다. 찾은 입력 중 가장 짧은 입력을 counterexample 로 반환한다.
+//	@Accept			json
+//	@Produce		json
+//	@Tags			Problem
+//	@Param			requestBody	body		StressTestRequest	true	"requestBody"
+//	@Success		200			{object}	StressTestResponse
+//	@Failure		400			{object}	StressTestResponse
+//	@Failure		429			{object}	QuotaErrorResponse
+//	@Failure		500			{object}	StressTestResponse
+//	@Router			/problem/stress [post]
+func (handler *StressHandler) StressTest() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req StressTestRequest
+		if err := c.BodyParser(&req); err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(StressTestResponse{
+				Error: err.Error(),
+			})
+		}
+
+		sources := []SourceRequest{req.Candidate, req.Brute, req.Generator}
+		for _, source := range sources {
+			if !IsSupported(source.Language) {
+				err := errUnsupportedLanguage(source.Language)
+				log.Error(err)
+				return c.Status(fiber.StatusBadRequest).JSON(StressTestResponse{
+					Error: err.Error(),
+				})
+			}
+		}
+
+		dto := StressTestDTO{
+			Candidate:  SourceCode{Language: req.Candidate.Language, Code: DecodeBase64([]byte(req.Candidate.Code))},
+			Brute:      SourceCode{Language: req.Brute.Language, Code: DecodeBase64([]byte(req.Brute.Code))},
+			Generator:  SourceCode{Language: req.Generator.Language, Code: DecodeBase64([]byte(req.Generator.Code))},
+			TimeBudget: req.TimeBudget,
+			TimeLimit:  req.TimeLimit,
+			MaxSize:    req.MaxSize,
+		}
+
+		response, err := handler.service.StressTest(c.UserContext(), dto)
+		if errors.Is(err, services.ErrInvalidStress) {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(StressTestResponse{
+				Error: err.Error(),
+			})
+		}
+		if errors.Is(err, services.ErrDraining) {
+			log.Error(err)
+			return c.Status(fiber.StatusServiceUnavailable).JSON(StressTestResponse{
+				Error: err.Error(),
+			})
+		}
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusInternalServerError).JSON(StressTestResponse{
+				Error: err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(response)
+	}
+}
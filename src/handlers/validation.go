@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/commands"
+	. "leita/src/entities"
+	"leita/src/services"
+	. "leita/src/utils"
+)
+
+type ValidationHandler struct {
+	service *services.ValidationService
+}
+
+func NewValidationHandler() (*ValidationHandler, error) {
+	service, err := services.NewValidationService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &ValidationHandler{
+		service: service,
+	}, nil
+}
+
+// Validate godoc
+//
+//	@Description	문제의 검증기로 모든 테스트 입력을 검증하고 테스트별 결과를 반환한다. INVALID 인 문제는 채점하지 않는다.
+//	@Produce		json
+//	@Tags			Validation
+//	@Param			problemId	path		string	true	"problemId"
+//	@Success		200			{object}	ValidationResponse
+//	@Failure		500			{object}	ValidationResponse
+//	@Router			/problem/{problemId}/validate [post]
+func (handler *ValidationHandler) Validate() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		problemId, err := strconv.Atoi(c.Params("problemId"))
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(ValidationResponse{
+				Error: err.Error(),
+			})
+		}
+
+		response, err := handler.service.Validate(c.UserContext(), problemId)
+		return validationResponse(c, problemId, response, err)
+	}
+}
+
+// SetValidator godoc
+//
+//	@Description	문제의 검증기를 등록하고 바로 모든 테스트 입력을 검증한다. code 는 base64 로 보낸다.
+//	@Accept			json
+//	@Produce		json
+//	@Tags			Validation
+//	@Param			problemId	path		string				true	"problemId"
+//	@Param			requestBody	body		SetValidatorRequest	true	"requestBody"
+//	@Success		200			{object}	ValidationResponse
+//	@Failure		400			{object}	ValidationResponse
+//	@Failure		500			{object}	ValidationResponse
+//	@Router			/problem/{problemId}/validator [put]
+func (handler *ValidationHandler) SetValidator() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		problemId, err := strconv.Atoi(c.Params("problemId"))
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(ValidationResponse{
+				Error: err.Error(),
+			})
+		}
+
+		var req SetValidatorRequest
+		if err := c.BodyParser(&req); err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(ValidationResponse{
+				ProblemId: problemId,
+				Error:     err.Error(),
+			})
+		}
+
+		if !IsSupported(req.Language) {
+			err := errUnsupportedLanguage(req.Language)
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(ValidationResponse{
+				ProblemId: problemId,
+				Error:     err.Error(),
+			})
+		}
+
+		code := DecodeBase64([]byte(req.Code))
+
+		response, err := handler.service.SetValidator(c.UserContext(), problemId, req.Language, code)
+		return validationResponse(c, problemId, response, err)
+	}
+}
+
+// GetValidation godoc
+//
+//	@Description	문제의 마지막 검증 결과를 반환한다. 검증한 적이 없으면 상태가 NONE 이다.
+//	@Produce		json
+//	@Tags			Validation
+//	@Param			problemId	path		string	true	"problemId"
+//	@Success		200			{object}	ValidationResponse
+//	@Failure		500			{object}	ValidationResponse
+//	@Router			/problem/{problemId}/validation [get]
+func (handler *ValidationHandler) GetValidation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		problemId, err := strconv.Atoi(c.Params("problemId"))
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(ValidationResponse{
+				Error: err.Error(),
+			})
+		}
+
+		response, err := handler.service.GetValidation(problemId)
+		return validationResponse(c, problemId, response, err)
+	}
+}
+
+func validationResponse(c *fiber.Ctx, problemId int, response ValidationResponse, err error) error {
+	if errors.Is(err, services.ErrDraining) {
+		log.Error(err)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(ValidationResponse{
+			ProblemId: problemId,
+			Error:     err.Error(),
+		})
+	}
+	if err != nil {
+		log.Error(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(ValidationResponse{
+			ProblemId: problemId,
+			Error:     err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/commands"
+	. "leita/src/entities"
+	"leita/src/services"
+	. "leita/src/utils"
+)
+
+type VerificationHandler struct {
+	service *services.VerificationService
+}
+
+func NewVerificationHandler() (*VerificationHandler, error) {
+	service, err := services.NewVerificationService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &VerificationHandler{
+		service: service,
+	}, nil
+}
+
+// AddSolution godoc
+//
+//	@Description	문제에 태그가 붙은 풀이를 붙인다. 같은 이름의 풀이가 있으면 바꾸고, main 태그 풀이는 하나만 남긴다.
+//	@Accept			json
+//	@Produce		json
+//	@Tags			Verification
+//	@Param			problemId	path	string				true	"problemId"
+//	@Param			requestBody	body	AddSolutionRequest	true	"requestBody"
+//	@Success		204
+//	@Failure		400	{object}	VerificationResponse
+//	@Failure		500	{object}	VerificationResponse
+//	@Router			/problem/{problemId}/solutions [post]
+func (handler *VerificationHandler) AddSolution() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		problemId, err := strconv.Atoi(c.Params("problemId"))
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(VerificationResponse{
+				Error: err.Error(),
+			})
+		}
+
+		var req AddSolutionRequest
+		if err := c.BodyParser(&req); err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(VerificationResponse{
+				ProblemId: problemId,
+				Error:     err.Error(),
+			})
+		}
+
+		if !IsSupported(req.Language) {
+			err := errUnsupportedLanguage(req.Language)
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(VerificationResponse{
+				ProblemId: problemId,
+				Error:     err.Error(),
+			})
+		}
+
+		dto := AddSolutionDTO{
+			ProblemId: problemId,
+			Name:      req.Name,
+			Tag:       req.Tag,
+			SourceCode: SourceCode{
+				Language: req.Language,
+				Code:     DecodeBase64([]byte(req.Code)),
+			},
+		}
+
+		err = handler.service.AddSolution(dto)
+		if errors.Is(err, services.ErrInvalidSolution) {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(VerificationResponse{
+				ProblemId: problemId,
+				Error:     err.Error(),
+			})
+		}
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusInternalServerError).JSON(VerificationResponse{
+				ProblemId: problemId,
+				Error:     err.Error(),
+			})
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// Verify godoc
+//
+//	@Description	문제에 붙은 풀이를 모두 일반 제출처럼 채점하는 작업을 시작한다.
+//	@Description	태그와 결과가 다른 풀이는 mismatches 에, 가장 느린 정답 풀이는 slowestCorrect 에 담긴다.
+//	@Produce		json
+//	@Tags			Verification
+//	@Param			problemId	path		string	true	"problemId"
+//	@Success		202			{object}	VerificationResponse
+//	@Failure		500			{object}	VerificationResponse
+//	@Router			/problem/{problemId}/verify [post]
+func (handler *VerificationHandler) Verify() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		problemId, err := strconv.Atoi(c.Params("problemId"))
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusBadRequest).JSON(VerificationResponse{
+				Error: err.Error(),
+			})
+		}
+
+		if services.IsDraining() {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(VerificationResponse{
+				ProblemId: problemId,
+				Error:     services.ErrDraining.Error(),
+			})
+		}
+
+		response, err := handler.service.Verify(problemId)
+		if err != nil {
+			log.Error(err)
+			return c.Status(fiber.StatusInternalServerError).JSON(VerificationResponse{
+				ProblemId: problemId,
+				Error:     err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusAccepted).JSON(response)
+	}
+}
+
+// GetVerification godoc
+//
+//	@Produce	json
+//	@Tags		Verification
+//	@Param		problemId	path		string	true	"problemId"
+//	@Param		jobId		path		string	true	"jobId"
+//	@Success	200			{object}	VerificationResponse
+//	@Failure	404			{object}	VerificationResponse
+//	@Router		/problem/{problemId}/verify/{jobId} [get]
+func (handler *VerificationHandler) GetVerification() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		jobId := c.Params("jobId")
+
+		response, exists := handler.service.GetVerification(jobId)
+		if !exists {
+			return c.Status(fiber.StatusNotFound).JSON(VerificationResponse{
+				JobId: jobId,
+				Error: "verification job not found",
+			})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(response)
+	}
+}
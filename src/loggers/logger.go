@@ -0,0 +1,246 @@
+package loggers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.opentelemetry.io/otel/trace"
+	. "leita/src/utils"
+)
+
+const (
+	levelTrace = slog.LevelDebug - 4
+	levelFatal = slog.LevelError + 4
+	levelPanic = slog.LevelError + 8
+)
+
+var levelNames = map[slog.Level]string{
+	levelTrace: "TRACE",
+	levelFatal: "FATAL",
+	levelPanic: "PANIC",
+}
+
+// Logger 는 fiber 의 log 패키지가 사용하는 AllLogger 를 slog 로 구현한다.
+// log.WithContext(ctx) 로 얻은 로거는 ctx 에 담긴 제출 정보를 모든 줄에 붙인다.
+type Logger struct {
+	logger *slog.Logger
+	level  *slog.LevelVar
+	json   bool
+}
+
+type submissionKey struct{}
+
+type submission struct {
+	judgeType string
+	submitId  int
+	problemId int
+	language  string
+}
+
+var (
+	logCode     bool
+	logTestData bool
+)
+
+// Setup 은 환경 변수로 로거를 설정하고 fiber 의 기본 로거를 바꾼다.
+//
+//	LOG_FORMAT=json|text (기본 json)
+//	LOG_LEVEL=trace|debug|info|warn|error (기본 info)
+//	LOG_CODE=true 이면 제출 코드를, LOG_TESTDATA=true 이면 테스트 입출력을 로그에 남긴다
+func Setup() error {
+	level := new(slog.LevelVar)
+	if value := GetEnv("LOG_LEVEL"); value != "" {
+		parsed, err := parseLevel(value)
+		if err != nil {
+			return err
+		}
+		level.Set(parsed)
+	}
+
+	logCode, _ = strconv.ParseBool(GetEnv("LOG_CODE"))
+	logTestData, _ = strconv.ParseBool(GetEnv("LOG_TESTDATA"))
+
+	logger := &Logger{
+		level: level,
+		json:  GetEnv("LOG_FORMAT") != "text",
+	}
+	logger.SetOutput(os.Stdout)
+
+	log.SetLogger(logger)
+	return nil
+}
+
+func LogCode() bool {
+	return logCode
+}
+
+func LogTestData() bool {
+	return logTestData
+}
+
+// WithSubmission 은 이후 log.WithContext(ctx) 로 남기는 로그에 제출 정보를 붙인다
+func WithSubmission(ctx context.Context, judgeType string, submitId, problemId int, language string) context.Context {
+	return context.WithValue(ctx, submissionKey{}, submission{
+		judgeType: judgeType,
+		submitId:  submitId,
+		problemId: problemId,
+		language:  language,
+	})
+}
+
+// ctx 에 진행 중인 span 이 있으면 traceId, spanId 도 붙여서 트레이스와 로그를 이어 볼 수 있게 한다
+func (logger *Logger) WithContext(ctx context.Context) log.CommonLogger {
+	var attrs []any
+	if value, ok := ctx.Value(submissionKey{}).(submission); ok {
+		attrs = append(attrs,
+			slog.String("judgeType", value.judgeType),
+			slog.Int("submitId", value.submitId),
+			slog.Int("problemId", value.problemId),
+			slog.String("language", value.language),
+		)
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		attrs = append(attrs,
+			slog.String("traceId", spanContext.TraceID().String()),
+			slog.String("spanId", spanContext.SpanID().String()),
+		)
+	}
+	if len(attrs) == 0 {
+		return logger
+	}
+
+	return &Logger{
+		logger: logger.logger.With(attrs...),
+		level:  logger.level,
+		json:   logger.json,
+	}
+}
+
+func (logger *Logger) SetLevel(level log.Level) {
+	logger.level.Set(map[log.Level]slog.Level{
+		log.LevelTrace: levelTrace,
+		log.LevelDebug: slog.LevelDebug,
+		log.LevelInfo:  slog.LevelInfo,
+		log.LevelWarn:  slog.LevelWarn,
+		log.LevelError: slog.LevelError,
+		log.LevelFatal: levelFatal,
+		log.LevelPanic: levelPanic,
+	}[level])
+}
+
+func (logger *Logger) SetOutput(w io.Writer) {
+	options := &slog.HandlerOptions{
+		Level: logger.level,
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.LevelKey {
+				if name, exists := levelNames[attr.Value.Any().(slog.Level)]; exists {
+					attr.Value = slog.StringValue(name)
+				}
+			}
+			return attr
+		},
+	}
+
+	if logger.json {
+		logger.logger = slog.New(slog.NewJSONHandler(w, options))
+	} else {
+		logger.logger = slog.New(slog.NewTextHandler(w, options))
+	}
+}
+
+func (logger *Logger) Trace(v ...interface{}) { logger.log(levelTrace, fmt.Sprint(v...)) }
+func (logger *Logger) Debug(v ...interface{}) { logger.log(slog.LevelDebug, fmt.Sprint(v...)) }
+func (logger *Logger) Info(v ...interface{})  { logger.log(slog.LevelInfo, fmt.Sprint(v...)) }
+func (logger *Logger) Warn(v ...interface{})  { logger.log(slog.LevelWarn, fmt.Sprint(v...)) }
+func (logger *Logger) Error(v ...interface{}) { logger.log(slog.LevelError, fmt.Sprint(v...)) }
+func (logger *Logger) Fatal(v ...interface{}) { logger.fatal(fmt.Sprint(v...)) }
+func (logger *Logger) Panic(v ...interface{}) { logger.panic(fmt.Sprint(v...)) }
+
+func (logger *Logger) Tracef(format string, v ...interface{}) {
+	logger.log(levelTrace, fmt.Sprintf(format, v...))
+}
+func (logger *Logger) Debugf(format string, v ...interface{}) {
+	logger.log(slog.LevelDebug, fmt.Sprintf(format, v...))
+}
+func (logger *Logger) Infof(format string, v ...interface{}) {
+	logger.log(slog.LevelInfo, fmt.Sprintf(format, v...))
+}
+func (logger *Logger) Warnf(format string, v ...interface{}) {
+	logger.log(slog.LevelWarn, fmt.Sprintf(format, v...))
+}
+func (logger *Logger) Errorf(format string, v ...interface{}) {
+	logger.log(slog.LevelError, fmt.Sprintf(format, v...))
+}
+func (logger *Logger) Fatalf(format string, v ...interface{}) {
+	logger.fatal(fmt.Sprintf(format, v...))
+}
+func (logger *Logger) Panicf(format string, v ...interface{}) {
+	logger.panic(fmt.Sprintf(format, v...))
+}
+
+func (logger *Logger) Tracew(msg string, keysAndValues ...interface{}) {
+	logger.log(levelTrace, msg, keysAndValues...)
+}
+func (logger *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	logger.log(slog.LevelDebug, msg, keysAndValues...)
+}
+func (logger *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	logger.log(slog.LevelInfo, msg, keysAndValues...)
+}
+func (logger *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	logger.log(slog.LevelWarn, msg, keysAndValues...)
+}
+func (logger *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	logger.log(slog.LevelError, msg, keysAndValues...)
+}
+func (logger *Logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	logger.fatal(msg, keysAndValues...)
+}
+func (logger *Logger) Panicw(msg string, keysAndValues ...interface{}) {
+	logger.panic(msg, keysAndValues...)
+}
+
+func (logger *Logger) log(level slog.Level, msg string, keysAndValues ...interface{}) {
+	logger.logger.Log(context.Background(), level, msg, keysAndValues...)
+}
+
+func (logger *Logger) fatal(msg string, keysAndValues ...interface{}) {
+	logger.log(levelFatal, msg, keysAndValues...)
+	os.Exit(1)
+}
+
+func (logger *Logger) panic(msg string, keysAndValues ...interface{}) {
+	logger.log(levelPanic, msg, keysAndValues...)
+	panic(msg)
+}
+
+func parseLevel(value string) (slog.Level, error) {
+	switch strings.ToLower(value) {
+	case "trace":
+		return levelTrace, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("invalid LOG_LEVEL: %s", value)
+	}
+}
+
+// AccessLogFormat 은 fiber 의 logger 미들웨어가 LOG_FORMAT 에 맞춰 요청 로그를 남기도록 형식을 돌려준다
+func AccessLogFormat() string {
+	if GetEnv("LOG_FORMAT") == "text" {
+		return "${time} | ${status} | ${latency} | ${ip} | ${method} | ${path} | ${error}\n"
+	}
+	return `{"time":"${time}","level":"INFO","msg":"request","status":${status},"method":"${method}","path":"${path}","latency":"${latency}","ip":"${ip}","error":"${error}"}` + "\n"
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"leita/src/commands"
+)
+
+const namespace = "judge"
+
+var (
+	Submissions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "submissions_total",
+		Help:      "채점이 끝난 제출 수",
+	}, []string{"judge_type", "language", "result"})
+
+	UnknownErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "unknown_errors_total",
+		Help:      "UNKNOWN 결과로 끝난 채점 수",
+	}, []string{"judge_type", "language"})
+
+	CompileDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "compile_duration_seconds",
+		Help:      "소스 코드 빌드 시간",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2, 4, 8, 16, 32},
+	}, []string{"language", "result"})
+
+	TestCaseDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "testcase_duration_seconds",
+		Help:      "테스트 케이스 하나의 실행 시간",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10},
+	}, []string{"judge_type", "language", "result"})
+
+	QueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "queue_depth",
+		Help:      "실행 슬롯을 기다리는 프로그램 수",
+	})
+
+	ActiveWorkers = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_workers",
+		Help:      "실행 중인 프로그램 수",
+	})
+
+	DataSourceDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "datasource_duration_seconds",
+		Help:      "데이터베이스, 오브젝트 스토리지, Redis 요청 시간",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"source", "operation"})
+
+	TestCaseCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "testcase_cache_lookups_total",
+		Help:      "테스트 케이스 캐시 조회 수",
+	}, []string{"result"})
+
+	CallbackDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "callback_deliveries_total",
+		Help:      "채점 결과 콜백 전달 시도 수",
+	}, []string{"outcome"})
+
+	TestCaseCacheSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "testcase_cache_size_bytes",
+		Help:      "테스트 케이스 캐시가 차지하는 디스크 크기",
+	})
+)
+
+// ObserveDatabase 는 반환된 함수를 호출할 때까지의 시간을 기록한다
+//
+//	defer ObserveDatabase("get_problem_info")()
+func ObserveDatabase(operation string) func() {
+	return observeDataSource("database", operation)
+}
+
+func ObserveObjectStorage(operation string) func() {
+	return observeDataSource("object_storage", operation)
+}
+
+func ObserveRedis(operation string) func() {
+	return observeDataSource("redis", operation)
+}
+
+func observeDataSource(source, operation string) func() {
+	timer := prometheus.NewTimer(DataSourceDuration.WithLabelValues(source, operation))
+	return func() {
+		timer.ObserveDuration()
+	}
+}
+
+// LanguageLabel 은 요청에서 온 언어 이름이 레이블 종류를 늘리지 않도록, 채점할 수 있는 언어가 아니면 OTHER 로 바꾼다
+func LanguageLabel(language string) string {
+	if _, exists := commands.Commands[language]; !exists {
+		return "OTHER"
+	}
+
+	return language
+}
+
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.Handler())
+}
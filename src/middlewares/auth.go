@@ -0,0 +1,276 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/entities"
+	. "leita/src/utils"
+)
+
+// ApiKeyLocal 은 인증된 ApiKey 가 저장되는 Locals 키
+const ApiKeyLocal = "apiKey"
+
+const (
+	defaultMaxClockSkew = 5 * time.Minute
+	// 키 파일을 다시 읽었는지 확인하는 간격, 키 교체 시 서버를 재시작하지 않아도 된다
+	keyFileCheckInterval = 10 * time.Second
+)
+
+var (
+	errMissingCredentials = errors.New("missing api key")
+	errInvalidCredentials = errors.New("invalid api key")
+	errInvalidSignature   = errors.New("invalid signature")
+	errExpiredTimestamp   = errors.New("request timestamp out of range")
+)
+
+type Authenticator struct {
+	disabled     bool
+	keyFilePath  string
+	maxClockSkew time.Duration
+
+	mutex       sync.RWMutex
+	envKeys     []ApiKey
+	fileKeys    []ApiKey
+	fileModTime time.Time
+	checkedAt   time.Time
+}
+
+// NewAuthenticator 는 JUDGE_API_KEYS 와 JUDGE_API_KEYS_FILE 에서 키를 읽는다.
+// 키가 하나도 없으면 JUDGE_AUTH_DISABLED=true 가 아닌 한 에러를 반환한다.
+//
+//	JUDGE_API_KEYS=backend:secret:submit|run,admin:secret2:admin
+//	JUDGE_API_KEYS_FILE=keys.json ([{"id": "...", "secret": "...", "scopes": ["submit"], "expiresAt": "..."}])
+func NewAuthenticator() (*Authenticator, error) {
+	disabled, _ := strconv.ParseBool(GetEnv("JUDGE_AUTH_DISABLED"))
+
+	maxClockSkew := defaultMaxClockSkew
+	if value := GetEnv("JUDGE_AUTH_MAX_CLOCK_SKEW"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		maxClockSkew = parsed
+	}
+
+	envKeys, err := parseApiKeys(GetEnv("JUDGE_API_KEYS"))
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	authenticator := &Authenticator{
+		disabled:     disabled,
+		keyFilePath:  GetEnv("JUDGE_API_KEYS_FILE"),
+		maxClockSkew: maxClockSkew,
+		envKeys:      envKeys,
+	}
+
+	if err = authenticator.reloadKeyFile(); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	if disabled {
+		log.Warn("API 인증이 꺼져 있습니다")
+		return authenticator, nil
+	}
+
+	if len(authenticator.keys()) == 0 {
+		err = errors.New("no api keys configured, set JUDGE_API_KEYS or JUDGE_API_KEYS_FILE")
+		log.Error(err)
+		return nil, err
+	}
+
+	return authenticator, nil
+}
+
+// Authenticate 는 요청한 키를 확인해 Locals 에 저장한다. 키는 두 가지 방식으로 보낼 수 있다.
+//
+//   - X-Api-Key: {secret} 또는 Authorization: Bearer {secret}
+//   - X-Api-Key-Id: {id}, X-Timestamp: {unix seconds},
+//     X-Signature: hex(HMAC-SHA256(secret, timestamp + "\n" + method + "\n" + url + "\n" + body))
+func (authenticator *Authenticator) Authenticate() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if authenticator.disabled {
+			return c.Next()
+		}
+
+		key, err := authenticator.authenticate(c)
+		if err != nil {
+			log.Warn(err, ": ", c.Method(), " ", c.Path(), " from ", c.IP())
+			return c.Status(fiber.StatusUnauthorized).JSON(AuthErrorResponse{
+				Error: err.Error(),
+			})
+		}
+
+		c.Locals(ApiKeyLocal, key)
+		return c.Next()
+	}
+}
+
+// RequireScope 는 Authenticate 뒤에 붙여서 키가 주어진 범위 중 하나를 가졌는지 확인한다
+func (authenticator *Authenticator) RequireScope(scopes ...ApiKeyScopeEnum) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if authenticator.disabled {
+			return c.Next()
+		}
+
+		key, _ := c.Locals(ApiKeyLocal).(ApiKey)
+		for _, scope := range scopes {
+			if key.HasScope(scope) {
+				return c.Next()
+			}
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(AuthErrorResponse{
+			Error: "api key does not have required scope",
+		})
+	}
+}
+
+func (authenticator *Authenticator) authenticate(c *fiber.Ctx) (ApiKey, error) {
+	if err := authenticator.reloadKeyFile(); err != nil {
+		log.Error(err)
+	}
+
+	now := time.Now()
+
+	if keyId := c.Get("X-Api-Key-Id"); keyId != "" {
+		return authenticator.verifySignature(c, keyId, now)
+	}
+
+	secret := c.Get("X-Api-Key")
+	if secret == "" {
+		secret = strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	}
+	if secret == "" {
+		return ApiKey{}, errMissingCredentials
+	}
+
+	for _, key := range authenticator.keys() {
+		if subtle.ConstantTimeCompare([]byte(key.Secret), []byte(secret)) == 1 && key.IsActive(now) {
+			return key, nil
+		}
+	}
+
+	return ApiKey{}, errInvalidCredentials
+}
+
+func (authenticator *Authenticator) verifySignature(c *fiber.Ctx, keyId string, now time.Time) (ApiKey, error) {
+	timestamp := c.Get("X-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ApiKey{}, errExpiredTimestamp
+	}
+	if math.Abs(now.Sub(time.Unix(seconds, 0)).Seconds()) > authenticator.maxClockSkew.Seconds() {
+		return ApiKey{}, errExpiredTimestamp
+	}
+
+	signature, err := hex.DecodeString(c.Get("X-Signature"))
+	if err != nil {
+		return ApiKey{}, errInvalidSignature
+	}
+
+	// 교체 중에는 같은 id 로 여러 키가 있을 수 있으므로 모두 확인한다
+	for _, key := range authenticator.keys() {
+		if key.Id != keyId || !key.IsActive(now) {
+			continue
+		}
+
+		mac := hmac.New(sha256.New, []byte(key.Secret))
+		mac.Write([]byte(timestamp + "\n" + c.Method() + "\n" + c.OriginalURL() + "\n"))
+		mac.Write(c.Body())
+		if hmac.Equal(mac.Sum(nil), signature) {
+			return key, nil
+		}
+	}
+
+	return ApiKey{}, errInvalidSignature
+}
+
+func (authenticator *Authenticator) keys() []ApiKey {
+	authenticator.mutex.RLock()
+	defer authenticator.mutex.RUnlock()
+
+	keys := make([]ApiKey, 0, len(authenticator.envKeys)+len(authenticator.fileKeys))
+	keys = append(keys, authenticator.envKeys...)
+	keys = append(keys, authenticator.fileKeys...)
+	return keys
+}
+
+// reloadKeyFile 은 키 파일이 바뀌었을 때만 다시 읽는다
+func (authenticator *Authenticator) reloadKeyFile() error {
+	if authenticator.keyFilePath == "" {
+		return nil
+	}
+
+	authenticator.mutex.Lock()
+	defer authenticator.mutex.Unlock()
+
+	if time.Since(authenticator.checkedAt) < keyFileCheckInterval {
+		return nil
+	}
+	authenticator.checkedAt = time.Now()
+
+	info, err := os.Stat(authenticator.keyFilePath)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if info.ModTime().Equal(authenticator.fileModTime) {
+		return nil
+	}
+
+	content, err := os.ReadFile(authenticator.keyFilePath)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	var keys []ApiKey
+	if err = json.Unmarshal(content, &keys); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	authenticator.fileKeys = keys
+	authenticator.fileModTime = info.ModTime()
+	log.Info("API 키 파일을 다시 읽었습니다: ", len(keys), "개")
+	return nil
+}
+
+func parseApiKeys(value string) ([]ApiKey, error) {
+	keys := make([]ApiKey, 0)
+	if value == "" {
+		return keys, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(parts) != 3 || !AllString(parts...) {
+			return nil, errors.New("invalid JUDGE_API_KEYS entry, expected id:secret:scope|scope")
+		}
+
+		keys = append(keys, ApiKey{
+			Id:     parts[0],
+			Secret: parts[1],
+			Scopes: strings.Split(parts[2], "|"),
+		})
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,293 @@
+package middlewares
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	. "leita/src/entities"
+	. "leita/src/utils"
+)
+
+// UserKeyLocal, KeyIdLocal 은 IdentifyUser 가 구한 사용자 키와 API 키 구분자가 저장되는 Locals 키
+const (
+	UserKeyLocal = "userKey"
+	KeyIdLocal   = "keyId"
+)
+
+const (
+	defaultUserIdHeader     = "X-User-Id"
+	defaultRateLimitWindow  = time.Minute
+	defaultKeyRateLimit     = 600
+	defaultUserRateLimit    = 30
+	defaultMaxRunTestCases  = 10
+	defaultMaxRunInputBytes = 1 << 20
+	defaultMaxRunConcurrent = 2
+)
+
+var (
+	ErrTooManyTestCases   = errors.New("too many testcases")
+	ErrInputTooLarge      = errors.New("testcase input too large")
+	ErrTooManyRunningRuns = errors.New("too many concurrent runs")
+	ErrRateLimitExceeded  = errors.New("rate limit exceeded")
+)
+
+// RunQuota 는 사용자 정의 실행(RunProblem)의 요청 빈도, 테스트 케이스 수, 입력 크기, 동시 실행 수를 제한한다.
+// 사용자는 API 키와 JUDGE_USER_ID_HEADER(기본 X-User-Id) 헤더로 구분한다.
+type RunQuota struct {
+	userIdHeader     string
+	window           time.Duration
+	keyRateLimit     int
+	userRateLimit    int
+	maxTestCases     int
+	maxInputBytes    int
+	maxConcurrent    int
+	concurrentMutex  sync.Mutex
+	concurrentByUser map[string]int
+	rateMutex        sync.Mutex
+	rates            map[string]*rateWindow
+	ratesSweptAt     time.Time
+}
+
+type rateWindow struct {
+	count   int
+	resetAt time.Time
+}
+
+var (
+	runQuota     *RunQuota
+	runQuotaErr  error
+	runQuotaOnce sync.Once
+)
+
+func GetRunQuota() (*RunQuota, error) {
+	runQuotaOnce.Do(func() {
+		runQuota, runQuotaErr = NewRunQuota()
+	})
+
+	return runQuota, runQuotaErr
+}
+
+func NewRunQuota() (*RunQuota, error) {
+	quota := &RunQuota{
+		userIdHeader:     defaultUserIdHeader,
+		window:           defaultRateLimitWindow,
+		concurrentByUser: make(map[string]int),
+		rates:            make(map[string]*rateWindow),
+	}
+	if header := GetEnv("JUDGE_USER_ID_HEADER"); header != "" {
+		quota.userIdHeader = header
+	}
+
+	if value := GetEnv("JUDGE_RATE_LIMIT_WINDOW"); value != "" {
+		window, err := time.ParseDuration(value)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		quota.window = window
+	}
+
+	limits := []struct {
+		target       *int
+		key          string
+		defaultValue int
+	}{
+		{&quota.keyRateLimit, "JUDGE_RATE_LIMIT_PER_KEY", defaultKeyRateLimit},
+		{&quota.userRateLimit, "JUDGE_RATE_LIMIT_PER_USER", defaultUserRateLimit},
+		{&quota.maxTestCases, "JUDGE_RUN_MAX_TESTCASES", defaultMaxRunTestCases},
+		{&quota.maxInputBytes, "JUDGE_RUN_MAX_INPUT_BYTES", defaultMaxRunInputBytes},
+		{&quota.maxConcurrent, "JUDGE_RUN_MAX_CONCURRENT", defaultMaxRunConcurrent},
+	}
+	for _, limit := range limits {
+		*limit.target = limit.defaultValue
+		if value := GetEnv(limit.key); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				log.Error(err)
+				return nil, err
+			}
+			*limit.target = parsed
+		}
+	}
+
+	return quota, nil
+}
+
+// KeyRateLimit 는 API 키별 요청 빈도를 제한한다. 0 이하로 설정하면 제한하지 않는다
+func (quota *RunQuota) KeyRateLimit() fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        quota.keyRateLimit,
+		Expiration: quota.window,
+		Next: func(c *fiber.Ctx) bool {
+			return quota.keyRateLimit <= 0
+		},
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return "key:" + apiKeyId(c.Locals(ApiKeyLocal), c.IP())
+		},
+		LimitReached: rateLimitReached,
+	})
+}
+
+// UserRateLimit 는 사용자 헤더가 있는 요청의 사용자별 요청 빈도를 제한한다
+func (quota *RunQuota) UserRateLimit() fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        quota.userRateLimit,
+		Expiration: quota.window,
+		Next: func(c *fiber.Ctx) bool {
+			return quota.userRateLimit <= 0 || c.Get(quota.userIdHeader) == ""
+		},
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return "user:" + quota.UserKey(c)
+		},
+		LimitReached: rateLimitReached,
+	})
+}
+
+// IdentifyUser 는 사용자 키와 API 키 구분자를 Locals 에 저장한다. WebSocket 처럼 요청 밖에서 Acquire, AllowMessage 를 호출할 때 쓴다
+func (quota *RunQuota) IdentifyUser() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(UserKeyLocal, quota.UserKey(c))
+		c.Locals(KeyIdLocal, apiKeyId(c.Locals(ApiKeyLocal), c.IP()))
+		return c.Next()
+	}
+}
+
+// AllowMessage 는 WebSocket 처럼 연결 하나로 여러 요청을 보낼 때 요청마다 API 키별, 사용자별 빈도를 확인한다.
+// 한도는 KeyRateLimit, UserRateLimit 과 같고 횟수는 따로 센다
+func (quota *RunQuota) AllowMessage(keyId, userKey string) error {
+	if !quota.allow("key:"+keyId, quota.keyRateLimit) {
+		return ErrRateLimitExceeded
+	}
+
+	// 사용자 헤더가 없으면 사용자 키가 API 키 구분자와 같다
+	if userKey != keyId && !quota.allow("user:"+userKey, quota.userRateLimit) {
+		return ErrRateLimitExceeded
+	}
+
+	return nil
+}
+
+// allow 는 window 마다 limit 번까지 허용한다. 0 이하면 제한하지 않는다
+func (quota *RunQuota) allow(key string, limit int) bool {
+	if limit <= 0 {
+		return true
+	}
+
+	now := time.Now()
+
+	quota.rateMutex.Lock()
+	defer quota.rateMutex.Unlock()
+
+	if now.Sub(quota.ratesSweptAt) > quota.window {
+		for rateKey, rate := range quota.rates {
+			if now.After(rate.resetAt) {
+				delete(quota.rates, rateKey)
+			}
+		}
+		quota.ratesSweptAt = now
+	}
+
+	rate, exists := quota.rates[key]
+	if !exists || now.After(rate.resetAt) {
+		rate = &rateWindow{resetAt: now.Add(quota.window)}
+		quota.rates[key] = rate
+	}
+
+	if rate.count >= limit {
+		return false
+	}
+	rate.count++
+	return true
+}
+
+// LimitConcurrency 는 요청이 끝날 때까지 사용자별 동시 실행 수를 하나 차지한다
+func (quota *RunQuota) LimitConcurrency() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := quota.UserKey(c)
+		if err := quota.Acquire(user); err != nil {
+			return c.Status(fiber.StatusTooManyRequests).JSON(QuotaErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		defer quota.Release(user)
+
+		return c.Next()
+	}
+}
+
+func (quota *RunQuota) Acquire(user string) error {
+	if quota.maxConcurrent <= 0 {
+		return nil
+	}
+
+	quota.concurrentMutex.Lock()
+	defer quota.concurrentMutex.Unlock()
+
+	if quota.concurrentByUser[user] >= quota.maxConcurrent {
+		return ErrTooManyRunningRuns
+	}
+	quota.concurrentByUser[user]++
+	return nil
+}
+
+func (quota *RunQuota) Release(user string) {
+	if quota.maxConcurrent <= 0 {
+		return
+	}
+
+	quota.concurrentMutex.Lock()
+	defer quota.concurrentMutex.Unlock()
+
+	quota.concurrentByUser[user]--
+	if quota.concurrentByUser[user] <= 0 {
+		delete(quota.concurrentByUser, user)
+	}
+}
+
+// CheckTestCases 는 테스트 케이스 수와 입력 크기를 확인한다. 입력 크기는 base64 를 푼 크기의 합이다
+func (quota *RunQuota) CheckTestCases(testCases []TestCase) error {
+	if quota.maxTestCases > 0 && len(testCases) > quota.maxTestCases {
+		return ErrTooManyTestCases
+	}
+
+	if quota.maxInputBytes > 0 {
+		inputBytes := 0
+		for _, testCase := range testCases {
+			inputBytes += base64.StdEncoding.DecodedLen(len(testCase.Input))
+		}
+		if inputBytes > quota.maxInputBytes {
+			return ErrInputTooLarge
+		}
+	}
+
+	return nil
+}
+
+// UserKey 는 API 키와 사용자 헤더로 사용자를 구분한다. 사용자 헤더가 없으면 API 키 단위로 묶는다
+func (quota *RunQuota) UserKey(c *fiber.Ctx) string {
+	key := apiKeyId(c.Locals(ApiKeyLocal), c.IP())
+	if user := c.Get(quota.userIdHeader); user != "" {
+		return key + ":" + user
+	}
+	return key
+}
+
+// 인증이 꺼져 있으면 IP 로 구분한다
+func apiKeyId(local any, ip string) string {
+	if key, ok := local.(ApiKey); ok {
+		return key.Id
+	}
+	return "ip:" + ip
+}
+
+func rateLimitReached(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusTooManyRequests).JSON(QuotaErrorResponse{
+		Error: ErrRateLimitExceeded.Error(),
+	})
+}
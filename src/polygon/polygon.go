@@ -0,0 +1,362 @@
+package polygon
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// 압축을 풀었을 때 이보다 크면 거절한다. 가져오는 동안 모든 파일을 메모리에 올리고 base64 로 한 번 더 인코딩하므로
+// 실제로 쓰는 메모리는 이 크기의 몇 배다
+const MaxUncompressedSize = 256 << 20
+
+var (
+	ErrNoProblemXml = errors.New("problem.xml not found in package")
+	// problem.xml 의 경로는 오브젝트 스토리지 이름에 그대로 쓰이므로 패키지 루트 밖을 가리키면 거절한다
+	ErrInvalidPath = errors.New("invalid path in problem.xml")
+)
+
+// Package 는 Polygon 문제 패키지에서 채점에 필요한 내용만 읽은 것이다
+type Package struct {
+	ShortName string
+	// 언어별 문제 이름
+	Names map[string]string
+	// ms
+	TimeLimit int
+	// MB
+	MemoryLimit int
+	Tests       []Test
+	Checker     *Source
+	Validators  []Source
+	Solutions   []Solution
+	Statements  []Statement
+	// testlib.h 처럼 체커, 검증기를 빌드할 때 같은 디렉터리에 있어야 하는 파일
+	Resources []Source
+}
+
+type Test struct {
+	Input  []byte
+	Answer []byte
+	Sample bool
+	Method string
+}
+
+type Source struct {
+	// 패키지 안의 경로
+	Path string
+	// Polygon 의 소스 종류, cpp.g++17 같은 값
+	Type string
+	// Commands 의 언어, 채점 서버가 지원하지 않으면 빈 값
+	Language string
+	// testlib 표준 체커면 std::wcmp.cpp 같은 이름
+	Name    string
+	Content []byte
+}
+
+type Solution struct {
+	Source
+	// main, accepted, wrong-answer, time-limit-exceeded ...
+	Tag string
+}
+
+type Statement struct {
+	Language string
+	Path     string
+	Type     string
+	Content  []byte
+}
+
+type problemXml struct {
+	ShortName string `xml:"short-name,attr"`
+	Names     []struct {
+		Language string `xml:"language,attr"`
+		Value    string `xml:"value,attr"`
+	} `xml:"names>name"`
+	Statements []struct {
+		Language string `xml:"language,attr"`
+		Path     string `xml:"path,attr"`
+		Type     string `xml:"type,attr"`
+	} `xml:"statements>statement"`
+	Testsets []struct {
+		Name              string `xml:"name,attr"`
+		TimeLimit         int    `xml:"time-limit"`
+		MemoryLimit       int64  `xml:"memory-limit"`
+		TestCount         int    `xml:"test-count"`
+		InputPathPattern  string `xml:"input-path-pattern"`
+		AnswerPathPattern string `xml:"answer-path-pattern"`
+		Tests             []struct {
+			Method string `xml:"method,attr"`
+			Sample bool   `xml:"sample,attr"`
+		} `xml:"tests>test"`
+	} `xml:"judging>testset"`
+	Resources []struct {
+		Path string `xml:"path,attr"`
+	} `xml:"files>resources>file"`
+	Checker *struct {
+		Name   string    `xml:"name,attr"`
+		Source sourceXml `xml:"source"`
+	} `xml:"assets>checker"`
+	Validators []struct {
+		Source sourceXml `xml:"source"`
+	} `xml:"assets>validators>validator"`
+	Solutions []struct {
+		Tag    string    `xml:"tag,attr"`
+		Source sourceXml `xml:"source"`
+	} `xml:"assets>solutions>solution"`
+}
+
+type sourceXml struct {
+	Path string `xml:"path,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// Parse 는 Polygon 패키지 zip 을 읽는다. problem.xml 이 하위 디렉터리에 있으면 그 디렉터리를 패키지 루트로 본다.
+// 생성 테스트의 입력과 정답 파일이 모두 들어 있는 full 패키지여야 한다
+func Parse(archive *zip.Reader) (*Package, error) {
+	files, root, err := indexFiles(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	read := func(name string) ([]byte, error) {
+		name, err := CleanPath(name)
+		if err != nil {
+			return nil, err
+		}
+
+		file, exists := files[path.Join(root, name)]
+		if !exists {
+			return nil, fmt.Errorf("%s not found in package", name)
+		}
+		return readFile(file)
+	}
+
+	content, err := read("problem.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	var problem problemXml
+	if err = xml.Unmarshal(content, &problem); err != nil {
+		return nil, fmt.Errorf("invalid problem.xml: %w", err)
+	}
+
+	if len(problem.Testsets) == 0 {
+		return nil, errors.New("problem.xml has no testset")
+	}
+	testset := problem.Testsets[0]
+	for _, candidate := range problem.Testsets {
+		if candidate.Name == "tests" {
+			testset = candidate
+			break
+		}
+	}
+
+	if testset.TimeLimit <= 0 || testset.MemoryLimit <= 0 {
+		return nil, errors.New("problem.xml has no time or memory limit")
+	}
+
+	pkg := &Package{
+		ShortName:   problem.ShortName,
+		Names:       make(map[string]string),
+		TimeLimit:   testset.TimeLimit,
+		MemoryLimit: int(testset.MemoryLimit >> 20),
+	}
+
+	for _, name := range problem.Names {
+		pkg.Names[name.Language] = name.Value
+	}
+
+	testCount := testset.TestCount
+	if testCount == 0 {
+		testCount = len(testset.Tests)
+	}
+	for i := 1; i <= testCount; i++ {
+		test := Test{}
+		if i <= len(testset.Tests) {
+			test.Method = testset.Tests[i-1].Method
+			test.Sample = testset.Tests[i-1].Sample
+		}
+
+		if test.Input, err = read(formatTestPath(testset.InputPathPattern, i)); err != nil {
+			return nil, fmt.Errorf("test %d: %w, build a full package with generated tests", i, err)
+		}
+		if test.Answer, err = read(formatTestPath(testset.AnswerPathPattern, i)); err != nil {
+			return nil, fmt.Errorf("test %d: %w, build a full package with answers", i, err)
+		}
+
+		pkg.Tests = append(pkg.Tests, test)
+	}
+	if len(pkg.Tests) == 0 {
+		return nil, errors.New("package has no tests")
+	}
+
+	if problem.Checker != nil && problem.Checker.Source.Path != "" {
+		checker, err := readSource(read, problem.Checker.Source)
+		if err != nil {
+			return nil, err
+		}
+		checker.Name = problem.Checker.Name
+		pkg.Checker = &checker
+	}
+
+	for _, validator := range problem.Validators {
+		source, err := readSource(read, validator.Source)
+		if err != nil {
+			return nil, err
+		}
+		pkg.Validators = append(pkg.Validators, source)
+	}
+
+	for _, solution := range problem.Solutions {
+		source, err := readSource(read, solution.Source)
+		if err != nil {
+			return nil, err
+		}
+		pkg.Solutions = append(pkg.Solutions, Solution{Source: source, Tag: solution.Tag})
+	}
+
+	for _, resource := range problem.Resources {
+		source, err := readSource(read, sourceXml{Path: resource.Path})
+		if err != nil {
+			return nil, err
+		}
+		pkg.Resources = append(pkg.Resources, source)
+	}
+
+	for _, statement := range problem.Statements {
+		statementPath, err := CleanPath(statement.Path)
+		if err != nil {
+			return nil, err
+		}
+		content, err := read(statementPath)
+		if err != nil {
+			return nil, err
+		}
+		pkg.Statements = append(pkg.Statements, Statement{
+			Language: statement.Language,
+			Path:     statementPath,
+			Type:     statement.Type,
+			Content:  content,
+		})
+	}
+
+	return pkg, nil
+}
+
+// Language 는 Polygon 소스 종류를 채점 서버의 언어로 바꾼다. 지원하지 않으면 빈 값을 반환한다
+func Language(sourceType string) string {
+	prefixes := []struct {
+		prefix   string
+		language string
+	}{
+		{"cpp.", "CPP"},
+		{"c.", "C"},
+		{"java", "JAVA"},
+		{"kotlin", "KOTLIN"},
+		{"python.3", "PYTHON"},
+		{"python.pypy3", "PYTHON"},
+		{"go", "GO"},
+		{"js", "JAVASCRIPT"},
+		{"swift", "SWIFT"},
+	}
+
+	for _, candidate := range prefixes {
+		if strings.HasPrefix(sourceType, candidate.prefix) {
+			return candidate.language
+		}
+	}
+
+	return ""
+}
+
+func indexFiles(archive *zip.Reader) (map[string]*zip.File, string, error) {
+	files := make(map[string]*zip.File, len(archive.File))
+	var total uint64
+	var roots []string
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		total += file.UncompressedSize64
+		if total > MaxUncompressedSize {
+			return nil, "", fmt.Errorf("package is larger than %dMB uncompressed", MaxUncompressedSize>>20)
+		}
+
+		name := path.Clean(file.Name)
+		files[name] = file
+		if path.Base(name) == "problem.xml" {
+			roots = append(roots, path.Dir(name))
+		}
+	}
+
+	if len(roots) == 0 {
+		return nil, "", ErrNoProblemXml
+	}
+
+	// 가장 바깥에 있는 problem.xml 을 쓴다
+	sort.Slice(roots, func(i, j int) bool {
+		return len(roots[i]) < len(roots[j])
+	})
+
+	return files, roots[0], nil
+}
+
+func readFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	// 헤더에 적힌 크기를 믿지 않고 실제로 읽는 양도 제한한다
+	content, err := io.ReadAll(io.LimitReader(reader, int64(file.UncompressedSize64)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(content)) > file.UncompressedSize64 {
+		return nil, fmt.Errorf("%s is larger than its header says", file.Name)
+	}
+
+	return content, nil
+}
+
+// CleanPath 는 패키지 루트에 대한 상대 경로를 정리한다. 절대 경로이거나 정리한 뒤에도 루트 밖을 가리키면 ErrInvalidPath 를 반환한다
+func CleanPath(name string) (string, error) {
+	cleaned := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if name == "" || path.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPath, name)
+	}
+
+	return cleaned, nil
+}
+
+func readSource(read func(string) ([]byte, error), source sourceXml) (Source, error) {
+	sourcePath, err := CleanPath(source.Path)
+	if err != nil {
+		return Source{}, err
+	}
+
+	content, err := read(sourcePath)
+	if err != nil {
+		return Source{}, err
+	}
+
+	return Source{
+		Path:     sourcePath,
+		Type:     source.Type,
+		Language: Language(source.Type),
+		Content:  content,
+	}, nil
+}
+
+// tests/%02d 같은 Polygon 경로 패턴에 테스트 번호를 넣는다
+func formatTestPath(pattern string, i int) string {
+	return fmt.Sprintf(pattern, i)
+}
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"github.com/gofiber/fiber/v2/log"
+	"leita/src/dataSources"
+	. "leita/src/entities"
+	"leita/src/metrics"
+)
+
+// CallbackRepository 는 끝내 전달하지 못한 채점 결과 콜백을 callback_dead_letter 테이블에 남긴다.
+//
+//	CREATE TABLE callback_dead_letter (
+//	    id           BIGINT        NOT NULL AUTO_INCREMENT PRIMARY KEY,
+//	    delivery_id  VARCHAR(64)   NOT NULL,
+//	    submit_id    BIGINT        NOT NULL,
+//	    callback_url VARCHAR(2048) NOT NULL,
+//	    payload      TEXT          NOT NULL,
+//	    attempts     INT           NOT NULL,
+//	    last_error   TEXT          NOT NULL,
+//	    created_at   DATETIME(3)   NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+//	    INDEX idx_callback_dead_letter_submit (submit_id)
+//	);
+//
+// X-Delivery-Id 는 제출이나 채점 작업에서 만들므로 UUID 보다 길 수 있다. 이미 만든 테이블은 열을 늘린다
+//
+//	ALTER TABLE callback_dead_letter MODIFY delivery_id VARCHAR(64) NOT NULL;
+type CallbackRepository struct {
+	dataSource *dataSources.DataSource
+}
+
+func NewCallbackRepository() (*CallbackRepository, error) {
+	dataSource, err := dataSources.GetDataSource()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &CallbackRepository{
+		dataSource: dataSource,
+	}, nil
+}
+
+func (repository *CallbackRepository) SaveDeadLetter(dto SaveDeadLetterDTO) error {
+	defer metrics.ObserveDatabase("save_callback_dead_letter")()
+
+	db := repository.dataSource.GetDatabase()
+
+	query := "INSERT INTO callback_dead_letter (delivery_id, submit_id, callback_url, payload, attempts, last_error) VALUES (?, ?, ?, ?, ?, ?);"
+	if _, err := db.Exec(query, dto.DeliveryId, dto.SubmitId, dto.CallbackUrl, dto.Payload, dto.Attempts, dto.LastError); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
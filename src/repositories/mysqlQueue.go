@@ -0,0 +1,214 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"leita/src/dataSources"
+	. "leita/src/entities"
+	"leita/src/metrics"
+	. "leita/src/utils"
+)
+
+// MysqlJudgeQueue 는 judge_queue 테이블을 큐로 쓴다. 여러 워커가 동시에 임대해도
+// SELECT ... FOR UPDATE SKIP LOCKED 로 같은 작업을 두 번 가져가지 않는다.
+//
+//	CREATE TABLE judge_queue (
+//	    id           BIGINT        NOT NULL AUTO_INCREMENT PRIMARY KEY,
+//	    submit_id    BIGINT        NOT NULL,
+//	    problem_id   INT           NOT NULL,
+//	    language     VARCHAR(20)   NOT NULL,
+//	    code         MEDIUMTEXT    NOT NULL,
+//	    callback_url VARCHAR(2048) NULL,
+//	    status       VARCHAR(10)   NOT NULL DEFAULT 'QUEUED',
+//	    attempts     INT           NOT NULL DEFAULT 0,
+//	    worker_id    VARCHAR(64)   NULL,
+//	    lease_until  DATETIME(3)   NULL,
+//	    result       VARCHAR(20)   NULL,
+//	    used_time    BIGINT        NULL,
+//	    used_memory  BIGINT        NULL,
+//	    error        TEXT          NULL,
+//	    created_at   DATETIME(3)   NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+//	    INDEX idx_judge_queue_status (status, language, id),
+//	    INDEX idx_judge_queue_lease (status, lease_until)
+//	);
+type MysqlJudgeQueue struct {
+	dataSource *dataSources.DataSource
+}
+
+func NewMysqlJudgeQueue() (*MysqlJudgeQueue, error) {
+	dataSource, err := dataSources.GetDataSource()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &MysqlJudgeQueue{
+		dataSource: dataSource,
+	}, nil
+}
+
+func (queue *MysqlJudgeQueue) Enqueue(job JudgeJob) (int64, error) {
+	defer metrics.ObserveDatabase("enqueue_judge_job")()
+
+	db := queue.dataSource.GetDatabase()
+
+	query := "INSERT INTO judge_queue (submit_id, problem_id, language, code, callback_url, status) VALUES (?, ?, ?, ?, NULLIF(?, ''), ?);"
+	result, err := db.Exec(query, job.SubmitId, job.ProblemId, job.Language, EncodeBase64(job.Code), job.CallbackUrl, JudgeJobQueued.String())
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	jobId, err := result.LastInsertId()
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return jobId, nil
+}
+
+func (queue *MysqlJudgeQueue) Lease(workerId string, languages []string, lease time.Duration) (*JudgeJob, error) {
+	defer metrics.ObserveDatabase("lease_judge_job")()
+
+	db := queue.dataSource.GetDatabase()
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if len(languages) == 0 {
+		return nil, nil
+	}
+
+	args := []any{JudgeJobQueued.String()}
+	for _, language := range languages {
+		args = append(args, language)
+	}
+
+	query := "SELECT id, submit_id, problem_id, language, code, COALESCE(callback_url, ''), attempts FROM judge_queue WHERE status = ? AND language IN (" + placeholders(len(languages)) + ") ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED;"
+	row := tx.QueryRow(query, args...)
+
+	var job JudgeJob
+	var code []byte
+	if err = row.Scan(&job.Id, &job.SubmitId, &job.ProblemId, &job.Language, &code, &job.CallbackUrl, &job.Attempts); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		log.Error(err)
+		return nil, err
+	}
+
+	query = "UPDATE judge_queue SET status = ?, worker_id = ?, lease_until = NOW(3) + INTERVAL ? MICROSECOND, attempts = attempts + 1 WHERE id = ?;"
+	if _, err = tx.Exec(query, JudgeJobRunning.String(), workerId, lease.Microseconds(), job.Id); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	job.Code = DecodeBase64(code)
+	job.Status = JudgeJobRunning.String()
+	job.Attempts++
+	job.WorkerId = workerId
+	return &job, nil
+}
+
+func (queue *MysqlJudgeQueue) Heartbeat(jobId int64, workerId string, lease time.Duration) error {
+	defer metrics.ObserveDatabase("heartbeat_judge_job")()
+
+	query := "UPDATE judge_queue SET lease_until = NOW(3) + INTERVAL ? MICROSECOND WHERE id = ? AND worker_id = ? AND status = ?;"
+	return queue.execOwned(query, lease.Microseconds(), jobId, workerId, JudgeJobRunning.String())
+}
+
+func (queue *MysqlJudgeQueue) Complete(jobId int64, workerId string, dto CompleteJudgeJobDTO) error {
+	defer metrics.ObserveDatabase("complete_judge_job")()
+
+	query := "UPDATE judge_queue SET status = ?, result = ?, used_time = ?, used_memory = ?, error = ?, lease_until = NULL WHERE id = ? AND worker_id = ? AND status = ?;"
+	return queue.execOwned(query, JudgeJobDone.String(), dto.Result, dto.UsedTime, dto.UsedMemory, dto.Error, jobId, workerId, JudgeJobRunning.String())
+}
+
+func (queue *MysqlJudgeQueue) Release(jobId int64, workerId string) error {
+	defer metrics.ObserveDatabase("release_judge_job")()
+
+	query := "UPDATE judge_queue SET status = ?, worker_id = NULL, lease_until = NULL, attempts = attempts - 1 WHERE id = ? AND worker_id = ? AND status = ?;"
+	return queue.execOwned(query, JudgeJobQueued.String(), jobId, workerId, JudgeJobRunning.String())
+}
+
+func (queue *MysqlJudgeQueue) RequeueExpired(maxAttempts int) (int, error) {
+	defer metrics.ObserveDatabase("requeue_judge_jobs")()
+
+	db := queue.dataSource.GetDatabase()
+
+	query := "UPDATE judge_queue SET status = ?, worker_id = NULL, lease_until = NULL, error = 'lease expired' WHERE status = ? AND lease_until < NOW(3) AND attempts >= ?;"
+	if _, err := db.Exec(query, JudgeJobFailed.String(), JudgeJobRunning.String(), maxAttempts); err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	query = "UPDATE judge_queue SET status = ?, worker_id = NULL, lease_until = NULL WHERE status = ? AND lease_until < NOW(3);"
+	result, err := db.Exec(query, JudgeJobQueued.String(), JudgeJobRunning.String())
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	requeued, err := result.RowsAffected()
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return int(requeued), nil
+}
+
+func (queue *MysqlJudgeQueue) GetJob(jobId int64) (*JudgeJob, error) {
+	defer metrics.ObserveDatabase("get_judge_job")()
+
+	db := queue.dataSource.GetDatabase()
+
+	query := "SELECT id, submit_id, problem_id, language, status, attempts, COALESCE(result, ''), COALESCE(used_time, 0), COALESCE(used_memory, 0), COALESCE(error, '') FROM judge_queue WHERE id = ?;"
+	row := db.QueryRow(query, jobId)
+
+	var job JudgeJob
+	if err := row.Scan(&job.Id, &job.SubmitId, &job.ProblemId, &job.Language, &job.Status, &job.Attempts, &job.Result, &job.UsedTime, &job.UsedMemory, &job.Error); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		log.Error(err)
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// 바뀐 행이 없으면 작업이 이미 다른 워커에게 넘어간 것이다
+func (queue *MysqlJudgeQueue) execOwned(query string, args ...any) error {
+	db := queue.dataSource.GetDatabase()
+
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if affected == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
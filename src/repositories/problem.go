@@ -1,9 +1,19 @@
 package repositories
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strconv"
+
 	"github.com/gofiber/fiber/v2/log"
 	"leita/src/dataSources"
 	. "leita/src/entities"
+	"leita/src/metrics"
 	. "leita/src/utils"
 )
 
@@ -12,7 +22,7 @@ type ProblemRepository struct {
 }
 
 func NewProblemRepository() (*ProblemRepository, error) {
-	dataSource, err := dataSources.NewDataSource()
+	dataSource, err := dataSources.GetDataSource()
 	if err != nil {
 		log.Error(err)
 		return nil, err
@@ -24,13 +34,20 @@ func NewProblemRepository() (*ProblemRepository, error) {
 }
 
 func (repository *ProblemRepository) GetProblemInfo(problemId int) (GetProblemInfoDAO, error) {
+	defer metrics.ObserveDatabase("get_problem_info")()
+
 	db := repository.dataSource.GetDatabase()
 
-	query := "SELECT limit_time, limit_memory FROM problem WHERE id = ?;"
-	row := db.QueryRow(query, problemId)
+	query := `SELECT p.limit_time, p.limit_memory, COALESCE(v.status, ?), COALESCE(v.data_version, ''), COALESCE(` + testcasesVersionColumn + `, '0'), COALESCE(c.checker, ?)
+FROM problem p
+LEFT JOIN problem_validation v ON v.problem_id = p.id
+LEFT JOIN problem_test_case_version t ON t.problem_id = p.id
+LEFT JOIN problem_checker c ON c.problem_id = p.id
+WHERE p.id = ?;`
+	row := db.QueryRow(query, ValidationNone.String(), CheckerExact.String(), problemId)
 
 	var dto GetProblemInfoDAO
-	if err := row.Scan(&dto.TimeLimit, &dto.MemoryLimit); err != nil {
+	if err := row.Scan(&dto.TimeLimit, &dto.MemoryLimit, &dto.ValidationStatus, &dto.ValidationVersion, &dto.TestcasesVersion, &dto.Checker); err != nil {
 		log.Error(err)
 		return GetProblemInfoDAO{}, err
 	}
@@ -70,36 +87,434 @@ func (repository *ProblemRepository) GetObjectsInFolder(folderPath string) ([][]
 	return contents, nil
 }
 
+// problem_test_case_version 은 문제의 테스트 케이스를 바꿀 때마다 올리는 버전으로, 테스트 케이스 캐시 키로 사용한다.
+// 테스트 케이스를 바꾸는 쪽은 백엔드를 포함해 모두 같은 트랜잭션에서 버전을 올려야 한다
+//
+//	CREATE TABLE problem_test_case_version (
+//	    problem_id INT         NOT NULL PRIMARY KEY,
+//	    version    BIGINT      NOT NULL,
+//	    updated_at DATETIME(6) NOT NULL
+//	);
+//
+// 데이터베이스를 다시 만들어 버전이 처음부터 시작해도 디스크에 남은 캐시를 쓰지 않도록 updated_at 도 키에 넣는다
+func (repository *ProblemRepository) GetTestcasesVersion(problemId int) (string, error) {
+	defer metrics.ObserveDatabase("get_testcases_version")()
+
+	return testcasesVersion(repository.dataSource.GetDatabase(), problemId)
+}
+
+// problem_test_case_version 을 t 로 읽을 때의 버전 문자열
+const testcasesVersionColumn = "CONCAT(t.version, '-', DATE_FORMAT(t.updated_at, '%Y%m%d%H%i%s%f'))"
+
+// testcasesVersion 은 *sql.DB 와 *sql.Tx 에서 모두 버전을 읽는다
+func testcasesVersion(db interface {
+	QueryRow(query string, args ...any) *sql.Row
+}, problemId int) (string, error) {
+	query := "SELECT " + testcasesVersionColumn + " FROM problem_test_case_version t WHERE t.problem_id = ?;"
+	row := db.QueryRow(query, problemId)
+
+	var version string
+	if err := row.Scan(&version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "0", nil
+		}
+		log.Error(err)
+		return "", err
+	}
+
+	return version, nil
+}
+
+func bumpTestcasesVersion(tx *sql.Tx, problemId int) error {
+	query := "INSERT INTO problem_test_case_version (problem_id, version, updated_at) VALUES (?, 1, NOW(6)) ON DUPLICATE KEY UPDATE version = version + 1, updated_at = NOW(6);"
+	if _, err := tx.Exec(query, problemId); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (repository *ProblemRepository) SaveObjectsInFolder(folderPath, dir string) (int, error) {
+	os := repository.dataSource.GetObjectStorage()
+	objects, err := os.ListObjects(folderPath)
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	for _, object := range objects {
+		if err = saveObject(os, *object.Name, filepath.Join(dir, filepath.Base(*object.Name))); err != nil {
+			log.Error(err)
+			return 0, err
+		}
+	}
+
+	return len(objects), nil
+}
+
+func saveObject(os *dataSources.ObjectStorage, objectName, path string) error {
+	reader, err := os.GetObjectReader(objectName)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	defer reader.Close()
+
+	return DecodeBase64ToFile(reader, path)
+}
+
 // 테스트케이스 임시로 db에서 가져오기
-func (repository *ProblemRepository) GetTestcases(problemId int) ([][]byte, error) {
+// 한 행씩 읽어 바로 파일로 쓰기 때문에 전체 테스트 케이스를 메모리에 올리지 않는다
+func (repository *ProblemRepository) SaveTestcases(problemId int, inputDir, outputDir string) (int, error) {
+	defer metrics.ObserveDatabase("get_testcases")()
+
 	db := repository.dataSource.GetDatabase()
 
 	query := "SELECT input, output FROM problem_test_cases WHERE problem_id = ?;"
 	rows, err := db.Query(query, problemId)
 	if err != nil {
 		log.Error(err)
-		return nil, err
+		return 0, err
 	}
 	defer rows.Close()
 
-	testCases := make([][]byte, 0)
-	inputs := make([][]byte, 0)
-	outputs := make([][]byte, 0)
+	testCaseNum := 0
 	for rows.Next() {
-		var input, output []byte
+		var input, output sql.RawBytes
 		if err = rows.Scan(&input, &output); err != nil {
 			log.Error(err)
-			return nil, err
+			return 0, err
+		}
+
+		inputFilePath := filepath.Join(inputDir, strconv.Itoa(testCaseNum)+".in")
+		if err = DecodeBase64ToFile(bytes.NewReader(input), inputFilePath); err != nil {
+			log.Error(err)
+			return 0, err
+		}
+
+		outputFilePath := filepath.Join(outputDir, strconv.Itoa(testCaseNum)+".out")
+		if err = DecodeBase64ToFile(bytes.NewReader(output), outputFilePath); err != nil {
+			log.Error(err)
+			return 0, err
 		}
-		inputs = append(inputs, DecodeBase64(input))
-		outputs = append(outputs, DecodeBase64(output))
+
+		testCaseNum++
 	}
 	if err = rows.Err(); err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return testCaseNum, nil
+}
+
+// ImportProblem 은 패키지의 파일을 오브젝트 스토리지에 올린 뒤, 제한, 출력 비교 방법과 테스트 케이스를 한 트랜잭션으로 바꾼다.
+// 테스트 케이스 버전이 바뀌므로 채점 서버의 테스트 케이스 캐시도 자연히 새로 채워진다
+//
+//	CREATE TABLE problem_checker (
+//	    problem_id INT         NOT NULL PRIMARY KEY,
+//	    checker    VARCHAR(10) NOT NULL
+//	);
+func (repository *ProblemRepository) ImportProblem(dto ImportProblemDTO) error {
+	os := repository.dataSource.GetObjectStorage()
+	for name, content := range dto.Objects {
+		if err := os.PutObject(name, EncodeBase64(content)); err != nil {
+			log.Error(err)
+			return err
+		}
+	}
+
+	defer metrics.ObserveDatabase("import_problem")()
+
+	db := repository.dataSource.GetDatabase()
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO problem (id, limit_time, limit_memory) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE limit_time = VALUES(limit_time), limit_memory = VALUES(limit_memory);"
+	if _, err = tx.Exec(query, dto.ProblemId, dto.TimeLimit, dto.MemoryLimit); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	query = "INSERT INTO problem_checker (problem_id, checker) VALUES (?, ?) ON DUPLICATE KEY UPDATE checker = VALUES(checker);"
+	if _, err = tx.Exec(query, dto.ProblemId, dto.Checker); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if err = replaceTestcases(tx, dto.ProblemId, dto.TestCases); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// ReplaceTestcases 는 문제의 테스트 케이스를 한 트랜잭션으로 모두 바꾼다
+func (repository *ProblemRepository) ReplaceTestcases(problemId int, testCases []PackageTestCase) error {
+	defer metrics.ObserveDatabase("replace_testcases")()
+
+	db := repository.dataSource.GetDatabase()
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = replaceTestcases(tx, problemId, testCases); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// AddTestcase 는 같은 입력이 없을 때만 테스트 케이스를 마지막에 붙이고, 붙였는지와 테스트 케이스 수를 반환한다.
+// 입력은 문제의 검증기로 검사한 것이어야 한다.
+// 큰 입력을 그대로 비교하지 않도록 입력의 SHA-256 을 input_hash 에 남겨 두고 그것으로 비교한다.
+// 테스트 케이스를 넣는 쪽은 백엔드를 포함해 모두 input_hash 를 채워야 하며, 비어 있는 행과는 비교하지 않는다
+//
+//	ALTER TABLE problem_test_cases
+//	    ADD COLUMN input_hash CHAR(64) NULL,
+//	    ADD INDEX idx_problem_test_cases_input_hash (problem_id, input_hash);
+//	UPDATE problem_test_cases SET input_hash = SHA2(FROM_BASE64(input), 256) WHERE input_hash IS NULL;
+func (repository *ProblemRepository) AddTestcase(problemId int, testCase PackageTestCase) (bool, int, error) {
+	defer metrics.ObserveDatabase("add_testcase")()
+
+	db := repository.dataSource.GetDatabase()
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Error(err)
+		return false, 0, err
+	}
+	defer tx.Rollback()
+
+	previousVersion, err := testcasesVersion(tx, problemId)
+	if err != nil {
+		log.Error(err)
+		return false, 0, err
+	}
+
+	inputHash := testcaseInputHash(testCase.Input)
+	query := `INSERT INTO problem_test_cases (problem_id, input, output, input_hash)
+SELECT ?, ?, ?, ? FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM problem_test_cases WHERE problem_id = ? AND input_hash = ?);`
+	result, err := tx.Exec(query, problemId, EncodeBase64(testCase.Input), EncodeBase64(testCase.Output), inputHash, problemId, inputHash)
+	if err != nil {
+		log.Error(err)
+		return false, 0, err
+	}
+
+	added, err := result.RowsAffected()
+	if err != nil {
+		log.Error(err)
+		return false, 0, err
+	}
+
+	if added > 0 {
+		if err = bumpTestcasesVersion(tx, problemId); err != nil {
+			log.Error(err)
+			return false, 0, err
+		}
+
+		// 붙이는 입력은 호출하는 쪽이 검증기로 이미 검사했으므로, 이전 버전에서 통과한 검증 결과는 새 버전에도 유효하다
+		version, err := testcasesVersion(tx, problemId)
+		if err != nil {
+			log.Error(err)
+			return false, 0, err
+		}
+
+		query = "UPDATE problem_validation SET data_version = ? WHERE problem_id = ? AND status = ? AND data_version = ?;"
+		if _, err = tx.Exec(query, version, problemId, ValidationValid.String(), previousVersion); err != nil {
+			log.Error(err)
+			return false, 0, err
+		}
+	}
+
+	query = "SELECT COUNT(*) FROM problem_test_cases WHERE problem_id = ?;"
+	var testCaseNum int
+	if err = tx.QueryRow(query, problemId).Scan(&testCaseNum); err != nil {
+		log.Error(err)
+		return false, 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error(err)
+		return false, 0, err
+	}
+
+	return added > 0, testCaseNum, nil
+}
+
+func replaceTestcases(tx *sql.Tx, problemId int, testCases []PackageTestCase) error {
+	query := "DELETE FROM problem_test_cases WHERE problem_id = ?;"
+	if _, err := tx.Exec(query, problemId); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	// SaveTestcases 가 넣은 순서대로 읽으므로 테스트 번호 순서대로 넣는다
+	query = "INSERT INTO problem_test_cases (problem_id, input, output, input_hash) VALUES (?, ?, ?, ?);"
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	defer stmt.Close()
+
+	for _, testCase := range testCases {
+		if _, err = stmt.Exec(problemId, EncodeBase64(testCase.Input), EncodeBase64(testCase.Output), testcaseInputHash(testCase.Input)); err != nil {
+			log.Error(err)
+			return err
+		}
+	}
+
+	return bumpTestcasesVersion(tx, problemId)
+}
+
+// MySQL 의 SHA2(FROM_BASE64(input), 256) 과 같은 값이다
+func testcaseInputHash(input []byte) string {
+	hash := sha256.Sum256(input)
+	return hex.EncodeToString(hash[:])
+}
+
+// GetObject 는 문제 패키지에서 가져온 파일을 읽는다
+func (repository *ProblemRepository) GetObject(name string) ([]byte, error) {
+	os := repository.dataSource.GetObjectStorage()
+	content, err := os.GetObject(name)
+	if err != nil {
 		log.Error(err)
 		return nil, err
 	}
-	testCases = append(testCases, inputs...)
-	testCases = append(testCases, outputs...)
 
-	return testCases, nil
+	return DecodeBase64(content), nil
+}
+
+// GetManifest 는 문제 패키지를 가져온 적이 없으면 nil 을 반환한다
+func (repository *ProblemRepository) GetManifest(problemId int) (*ProblemManifest, error) {
+	os := repository.dataSource.GetObjectStorage()
+	content, err := os.GetObject(filepath.Join("problems", strconv.Itoa(problemId), "manifest.json"))
+	if dataSources.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	var manifest ProblemManifest
+	if err = json.Unmarshal(DecodeBase64(content), &manifest); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
+func (repository *ProblemRepository) SaveManifest(manifest ProblemManifest) error {
+	content, err := json.Marshal(manifest)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return repository.SaveCode(filepath.Join("problems", strconv.Itoa(manifest.ProblemId), "manifest.json"), EncodeBase64(content))
+}
+
+// problem_validation 은 문제마다 마지막 검증 결과와 검증한 테스트 케이스 버전을 남긴다.
+// VALID 가 아니거나 검증한 뒤에 테스트 케이스가 바뀐 문제는 채점하지 않는다
+//
+//	CREATE TABLE problem_validation (
+//	    problem_id   INT         NOT NULL PRIMARY KEY,
+//	    status       VARCHAR(10) NOT NULL,
+//	    data_version VARCHAR(64) NOT NULL,
+//	    results      MEDIUMTEXT  NOT NULL,
+//	    validated_at DATETIME(3) NOT NULL
+//	);
+//
+// 이미 만든 테이블에는 열을 더한다. 빈 버전은 어떤 버전과도 같지 않으므로 다시 검증하기 전까지 채점하지 않는다
+//
+//	ALTER TABLE problem_validation ADD COLUMN data_version VARCHAR(64) NOT NULL DEFAULT '' AFTER status;
+func (repository *ProblemRepository) SaveValidation(response ValidationResponse) error {
+	defer metrics.ObserveDatabase("save_validation")()
+
+	db := repository.dataSource.GetDatabase()
+
+	results, err := json.Marshal(response)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	query := "INSERT INTO problem_validation (problem_id, status, data_version, results, validated_at) VALUES (?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE status = VALUES(status), data_version = VALUES(data_version), results = VALUES(results), validated_at = VALUES(validated_at);"
+	if _, err = db.Exec(query, response.ProblemId, response.Status, response.DataVersion, results, response.ValidatedAt); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetValidation 은 검증한 적이 없으면 nil 을 반환한다
+func (repository *ProblemRepository) GetValidation(problemId int) (*ValidationResponse, error) {
+	defer metrics.ObserveDatabase("get_validation")()
+
+	db := repository.dataSource.GetDatabase()
+
+	query := "SELECT results, data_version FROM problem_validation WHERE problem_id = ?;"
+	row := db.QueryRow(query, problemId)
+
+	var results []byte
+	var dataVersion string
+	if err := row.Scan(&results, &dataVersion); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		log.Error(err)
+		return nil, err
+	}
+
+	var response ValidationResponse
+	if err := json.Unmarshal(results, &response); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	// 해킹으로 테스트 케이스를 붙이면 열만 바뀐다
+	response.DataVersion = dataVersion
+
+	return &response, nil
+}
+
+// DeleteValidation 은 검증기가 없어진 문제의 검증 결과를 지워서 다시 채점할 수 있게 한다
+func (repository *ProblemRepository) DeleteValidation(problemId int) error {
+	defer metrics.ObserveDatabase("delete_validation")()
+
+	db := repository.dataSource.GetDatabase()
+
+	query := "DELETE FROM problem_validation WHERE problem_id = ?;"
+	if _, err := db.Exec(query, problemId); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
 }
@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/entities"
+	. "leita/src/utils"
+)
+
+// ErrLeaseLost 는 임대 기간이 지나 다른 워커에게 넘어간 작업을 갱신하거나 끝내려 할 때 반환된다
+var ErrLeaseLost = errors.New("judge job lease lost")
+
+// JudgeQueue 는 워커들이 함께 쓰는 채점 작업 큐다.
+// 워커는 작업을 임대(Lease)하고, 임대 기간 안에 Heartbeat 로 연장하면서 채점한 뒤 Complete 로 끝낸다.
+// 워커가 죽어서 임대 기간이 지난 작업은 RequeueExpired 가 다시 큐에 넣는다
+type JudgeQueue interface {
+	Enqueue(job JudgeJob) (int64, error)
+	// languages 중 하나로 된 작업만 가져온다. 가져올 작업이 없으면 nil 을 반환한다
+	Lease(workerId string, languages []string, lease time.Duration) (*JudgeJob, error)
+	Heartbeat(jobId int64, workerId string, lease time.Duration) error
+	Complete(jobId int64, workerId string, dto CompleteJudgeJobDTO) error
+	// Release 는 채점하지 못한 작업을 시도 횟수를 늘리지 않고 바로 큐에 돌려놓는다
+	Release(jobId int64, workerId string) error
+	// 시도 횟수가 maxAttempts 에 이른 작업은 다시 넣지 않고 FAILED 로 끝낸다
+	RequeueExpired(maxAttempts int) (int, error)
+	// 없는 작업이면 nil 을 반환한다
+	GetJob(jobId int64) (*JudgeJob, error)
+}
+
+// NewJudgeQueue 는 JUDGE_QUEUE_BACKEND(mysql|redis, 기본 mysql) 에 맞는 큐를 만든다
+func NewJudgeQueue() (JudgeQueue, error) {
+	switch backend := GetEnv("JUDGE_QUEUE_BACKEND"); backend {
+	case "", "mysql":
+		return NewMysqlJudgeQueue()
+	case "redis":
+		return NewRedisJudgeQueue()
+	default:
+		err := fmt.Errorf("invalid JUDGE_QUEUE_BACKEND: %s", backend)
+		log.Error(err)
+		return nil, err
+	}
+}
@@ -0,0 +1,297 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/redis/go-redis/v9"
+	"leita/src/dataSources"
+	. "leita/src/entities"
+	"leita/src/metrics"
+	. "leita/src/utils"
+)
+
+const (
+	redisJobSeqKey    = "judge:job:seq"
+	redisJobKeyPrefix = "judge:job:"
+	redisQueueKey     = "judge:queue:"
+	redisLeasesKey    = "judge:leases"
+	// 끝난 작업을 조회할 수 있도록 남겨두는 시간
+	redisJobRetention = 7 * 24 * time.Hour
+)
+
+// 임대 시각은 워커마다 시계가 다를 수 있으므로 Redis 서버의 TIME 으로 계산한다
+const redisNowScript = `
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+`
+
+var (
+	// 언어별 큐 중 가장 오래 기다린(번호가 가장 작은) 작업을 가져온다
+	// KEYS: leases, queue... / ARGV: workerId, leaseMs, jobKeyPrefix
+	redisLeaseScript = redis.NewScript(redisNowScript + `
+local queueKey, id
+for i = 2, #KEYS do
+	local head = redis.call('LINDEX', KEYS[i], -1)
+	if head and (not id or tonumber(head) < tonumber(id)) then
+		queueKey, id = KEYS[i], head
+	end
+end
+if not id then
+	return false
+end
+redis.call('RPOP', queueKey)
+local key = ARGV[3] .. id
+redis.call('HSET', key, 'status', 'RUNNING', 'workerId', ARGV[1])
+redis.call('HINCRBY', key, 'attempts', 1)
+redis.call('ZADD', KEYS[1], now + tonumber(ARGV[2]), id)
+return id
+`)
+
+	// KEYS: job, leases / ARGV: jobId, workerId, leaseMs
+	redisHeartbeatScript = redis.NewScript(redisNowScript + `
+if redis.call('HGET', KEYS[1], 'workerId') ~= ARGV[2] or redis.call('HGET', KEYS[1], 'status') ~= 'RUNNING' then
+	return 0
+end
+redis.call('ZADD', KEYS[2], now + tonumber(ARGV[3]), ARGV[1])
+return 1
+`)
+
+	// KEYS: job, leases / ARGV: jobId, workerId, result, usedTime, usedMemory, error, retentionSec
+	redisCompleteScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'workerId') ~= ARGV[2] or redis.call('HGET', KEYS[1], 'status') ~= 'RUNNING' then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'status', 'DONE', 'result', ARGV[3], 'usedTime', ARGV[4], 'usedMemory', ARGV[5], 'error', ARGV[6])
+redis.call('HDEL', KEYS[1], 'code')
+redis.call('EXPIRE', KEYS[1], ARGV[7])
+redis.call('ZREM', KEYS[2], ARGV[1])
+return 1
+`)
+
+	// KEYS: job, leases / ARGV: jobId, workerId, queueKeyPrefix
+	redisReleaseScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'workerId') ~= ARGV[2] or redis.call('HGET', KEYS[1], 'status') ~= 'RUNNING' then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'status', 'QUEUED')
+redis.call('HDEL', KEYS[1], 'workerId')
+redis.call('HINCRBY', KEYS[1], 'attempts', -1)
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('RPUSH', ARGV[3] .. redis.call('HGET', KEYS[1], 'language'), ARGV[1])
+return 1
+`)
+
+	// KEYS: leases / ARGV: maxAttempts, jobKeyPrefix, retentionSec, queueKeyPrefix
+	redisRequeueScript = redis.NewScript(redisNowScript + `
+local requeued = 0
+for _, id in ipairs(redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', now)) do
+	local key = ARGV[2] .. id
+	redis.call('ZREM', KEYS[1], id)
+	redis.call('HDEL', key, 'workerId')
+	if tonumber(redis.call('HGET', key, 'attempts') or '0') >= tonumber(ARGV[1]) then
+		redis.call('HSET', key, 'status', 'FAILED', 'error', 'lease expired')
+		redis.call('HDEL', key, 'code')
+		redis.call('EXPIRE', key, ARGV[3])
+	else
+		redis.call('HSET', key, 'status', 'QUEUED')
+		redis.call('RPUSH', ARGV[4] .. redis.call('HGET', key, 'language'), id)
+		requeued = requeued + 1
+	end
+end
+return requeued
+`)
+)
+
+// RedisJudgeQueue 는 작업을 해시 judge:job:{id} 에 두고, 대기 중인 작업 번호는 언어별 리스트 judge:queue:{language},
+// 임대 중인 작업은 임대 만료 시각을 점수로 하는 정렬 집합 judge:leases 에 둔다.
+// 상태를 바꾸는 연산은 모두 Lua 스크립트로 한 번에 처리해서 여러 워커가 같은 작업을 가져가지 않는다
+type RedisJudgeQueue struct {
+	client *redis.Client
+}
+
+func NewRedisJudgeQueue() (*RedisJudgeQueue, error) {
+	client, err := dataSources.GetRedis()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &RedisJudgeQueue{
+		client: client,
+	}, nil
+}
+
+func (queue *RedisJudgeQueue) Enqueue(job JudgeJob) (int64, error) {
+	defer metrics.ObserveRedis("enqueue_judge_job")()
+
+	ctx := context.Background()
+
+	jobId, err := queue.client.Incr(ctx, redisJobSeqKey).Result()
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	_, err = queue.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, redisJobKey(jobId),
+			"submitId", job.SubmitId,
+			"problemId", job.ProblemId,
+			"language", job.Language,
+			"code", EncodeBase64(job.Code),
+			"callbackUrl", job.CallbackUrl,
+			"status", JudgeJobQueued.String(),
+			"attempts", 0,
+		)
+		pipe.LPush(ctx, redisQueueKey+job.Language, jobId)
+		return nil
+	})
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return jobId, nil
+}
+
+func (queue *RedisJudgeQueue) Lease(workerId string, languages []string, lease time.Duration) (*JudgeJob, error) {
+	defer metrics.ObserveRedis("lease_judge_job")()
+
+	if len(languages) == 0 {
+		return nil, nil
+	}
+
+	ctx := context.Background()
+
+	keys := []string{redisLeasesKey}
+	for _, language := range languages {
+		keys = append(keys, redisQueueKey+language)
+	}
+
+	jobId, err := redisLeaseScript.Run(ctx, queue.client, keys,
+		workerId, lease.Milliseconds(), redisJobKeyPrefix,
+	).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		log.Error(err)
+		return nil, err
+	}
+
+	job, err := queue.getJob(ctx, jobId)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	if job == nil {
+		err = errors.New("leased judge job not found: " + strconv.FormatInt(jobId, 10))
+		log.Error(err)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (queue *RedisJudgeQueue) Heartbeat(jobId int64, workerId string, lease time.Duration) error {
+	defer metrics.ObserveRedis("heartbeat_judge_job")()
+
+	return queue.runOwned(redisHeartbeatScript,
+		[]string{redisJobKey(jobId), redisLeasesKey},
+		jobId, workerId, lease.Milliseconds(),
+	)
+}
+
+func (queue *RedisJudgeQueue) Complete(jobId int64, workerId string, dto CompleteJudgeJobDTO) error {
+	defer metrics.ObserveRedis("complete_judge_job")()
+
+	return queue.runOwned(redisCompleteScript,
+		[]string{redisJobKey(jobId), redisLeasesKey},
+		jobId, workerId, dto.Result, dto.UsedTime, dto.UsedMemory, dto.Error, int64(redisJobRetention.Seconds()),
+	)
+}
+
+func (queue *RedisJudgeQueue) Release(jobId int64, workerId string) error {
+	defer metrics.ObserveRedis("release_judge_job")()
+
+	return queue.runOwned(redisReleaseScript,
+		[]string{redisJobKey(jobId), redisLeasesKey},
+		jobId, workerId, redisQueueKey,
+	)
+}
+
+func (queue *RedisJudgeQueue) RequeueExpired(maxAttempts int) (int, error) {
+	defer metrics.ObserveRedis("requeue_judge_jobs")()
+
+	requeued, err := redisRequeueScript.Run(context.Background(), queue.client,
+		[]string{redisLeasesKey},
+		maxAttempts, redisJobKeyPrefix, int64(redisJobRetention.Seconds()), redisQueueKey,
+	).Int()
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return requeued, nil
+}
+
+func (queue *RedisJudgeQueue) GetJob(jobId int64) (*JudgeJob, error) {
+	defer metrics.ObserveRedis("get_judge_job")()
+
+	job, err := queue.getJob(context.Background(), jobId)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (queue *RedisJudgeQueue) getJob(ctx context.Context, jobId int64) (*JudgeJob, error) {
+	values, err := queue.client.HGetAll(ctx, redisJobKey(jobId)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	job := JudgeJob{
+		Id:          jobId,
+		Language:    values["language"],
+		Code:        DecodeBase64([]byte(values["code"])),
+		CallbackUrl: values["callbackUrl"],
+		Status:      values["status"],
+		WorkerId:    values["workerId"],
+		Result:      values["result"],
+		Error:       values["error"],
+	}
+	job.SubmitId, _ = strconv.Atoi(values["submitId"])
+	job.ProblemId, _ = strconv.Atoi(values["problemId"])
+	job.Attempts, _ = strconv.Atoi(values["attempts"])
+	job.UsedTime, _ = strconv.ParseInt(values["usedTime"], 10, 64)
+	job.UsedMemory, _ = strconv.ParseInt(values["usedMemory"], 10, 64)
+
+	return &job, nil
+}
+
+// 스크립트가 0 을 반환하면 작업이 이미 다른 워커에게 넘어간 것이다
+func (queue *RedisJudgeQueue) runOwned(script *redis.Script, keys []string, args ...any) error {
+	owned, err := script.Run(context.Background(), queue.client, keys, args...).Int()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if owned == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+func redisJobKey(jobId int64) string {
+	return redisJobKeyPrefix + strconv.FormatInt(jobId, 10)
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/gofiber/fiber/v2/log"
+	"leita/src/dataSources"
+	. "leita/src/entities"
+	"leita/src/metrics"
+)
+
+// SubmissionRepository 는 채점 서버가 받은 제출의 코드 digest 와 결과를 judge_submission 테이블에 남긴다.
+// 여러 노드와 워커가 같은 테이블을 보므로, 다른 노드에서 받았거나 재시작 전에 받은 제출과도 코드를 비교하고 결과를 돌려줄 수 있다.
+//
+//	CREATE TABLE judge_submission (
+//	    submit_id   BIGINT      NOT NULL PRIMARY KEY,
+//	    code_digest CHAR(64)    NOT NULL,
+//	    result      VARCHAR(20) NULL,
+//	    used_time   BIGINT      NULL,
+//	    used_memory BIGINT      NULL,
+//	    error       TEXT        NULL,
+//	    created_at  DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+//	    finished_at DATETIME(3) NULL
+//	);
+type SubmissionRepository struct {
+	dataSource *dataSources.DataSource
+}
+
+func NewSubmissionRepository() (*SubmissionRepository, error) {
+	dataSource, err := dataSources.GetDataSource()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &SubmissionRepository{
+		dataSource: dataSource,
+	}, nil
+}
+
+// ClaimSubmission 은 처음 받은 submitId 면 코드 digest 를 남기고, 이미 있으면 남아 있는 제출을 그대로 반환한다.
+// force 면 재채점처럼 코드 digest 를 바꾸고 남아 있던 결과를 지운다
+func (repository *SubmissionRepository) ClaimSubmission(submitId int, codeDigest string, force bool) (SubmissionDAO, error) {
+	defer metrics.ObserveDatabase("claim_submission")()
+
+	db := repository.dataSource.GetDatabase()
+
+	if force {
+		query := "INSERT INTO judge_submission (submit_id, code_digest) VALUES (?, ?) ON DUPLICATE KEY UPDATE code_digest = VALUES(code_digest), result = NULL, used_time = NULL, used_memory = NULL, error = NULL, finished_at = NULL;"
+		if _, err := db.Exec(query, submitId, codeDigest); err != nil {
+			log.Error(err)
+			return SubmissionDAO{}, err
+		}
+		return SubmissionDAO{CodeDigest: codeDigest}, nil
+	}
+
+	query := "INSERT INTO judge_submission (submit_id, code_digest) VALUES (?, ?) ON DUPLICATE KEY UPDATE submit_id = submit_id;"
+	if _, err := db.Exec(query, submitId, codeDigest); err != nil {
+		log.Error(err)
+		return SubmissionDAO{}, err
+	}
+
+	submission, err := repository.GetSubmission(submitId)
+	if err != nil {
+		log.Error(err)
+		return SubmissionDAO{}, err
+	}
+	if submission == nil {
+		err = errors.New("claimed submission not found")
+		log.Error(err)
+		return SubmissionDAO{}, err
+	}
+
+	return *submission, nil
+}
+
+// GetSubmission 은 받은 적이 없는 submitId 면 nil 을 반환한다
+func (repository *SubmissionRepository) GetSubmission(submitId int) (*SubmissionDAO, error) {
+	defer metrics.ObserveDatabase("get_submission")()
+
+	db := repository.dataSource.GetDatabase()
+
+	query := "SELECT code_digest, COALESCE(result, ''), COALESCE(used_time, 0), COALESCE(used_memory, 0), COALESCE(error, ''), finished_at FROM judge_submission WHERE submit_id = ?;"
+	row := db.QueryRow(query, submitId)
+
+	var submission SubmissionDAO
+	var finishedAt sql.NullTime
+	if err := row.Scan(&submission.CodeDigest, &submission.Result, &submission.UsedTime, &submission.UsedMemory, &submission.Error, &finishedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		log.Error(err)
+		return nil, err
+	}
+	submission.Finished = finishedAt.Valid
+	submission.FinishedAt = finishedAt.Time
+
+	return &submission, nil
+}
+
+// SaveSubmissionResult 는 코드 digest 가 같을 때만 결과를 남긴다. 그 사이에 재채점으로 코드가 바뀌었으면 쓰지 않는다
+func (repository *SubmissionRepository) SaveSubmissionResult(dto SaveSubmissionResultDTO) error {
+	defer metrics.ObserveDatabase("save_submission_result")()
+
+	db := repository.dataSource.GetDatabase()
+
+	query := "UPDATE judge_submission SET result = ?, used_time = ?, used_memory = ?, error = ?, finished_at = NOW(3) WHERE submit_id = ? AND code_digest = ?;"
+	if _, err := db.Exec(query, dto.Result, dto.UsedTime, dto.UsedMemory, dto.Error, dto.SubmitId, dto.CodeDigest); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2/log"
+	"leita/src/dataSources"
+	. "leita/src/entities"
+	"leita/src/metrics"
+	. "leita/src/utils"
+)
+
+// submit 테이블은 백엔드가 관리한다. 채점 서버는 아래 열만 읽고 쓰며, 백엔드와 스키마를 확정하기 전까지
+// 결과는 JUDGE_SUBMIT_WRITE_BACK 을 켠 경우에만 쓴다
+//
+//	submit (id, problem_id, language, result, used_time, used_memory, created_at)
+type SubmitRepository struct {
+	dataSource *dataSources.DataSource
+}
+
+func NewSubmitRepository() (*SubmitRepository, error) {
+	dataSource, err := dataSources.GetDataSource()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &SubmitRepository{
+		dataSource: dataSource,
+	}, nil
+}
+
+func (repository *SubmitRepository) GetSubmits(dto GetSubmitsDTO) ([]GetSubmitDAO, error) {
+	defer metrics.ObserveDatabase("get_submits")()
+
+	db := repository.dataSource.GetDatabase()
+
+	conditions := make([]string, 0)
+	args := make([]any, 0)
+	if dto.SubmitId != 0 {
+		conditions = append(conditions, "id = ?")
+		args = append(args, dto.SubmitId)
+	}
+	if dto.ProblemId != 0 {
+		conditions = append(conditions, "problem_id = ?")
+		args = append(args, dto.ProblemId)
+	}
+	if len(dto.Results) > 0 {
+		conditions = append(conditions, "result IN ("+placeholders(len(dto.Results))+")")
+		for _, result := range dto.Results {
+			args = append(args, result)
+		}
+	}
+	if len(dto.Languages) > 0 {
+		conditions = append(conditions, "language IN ("+placeholders(len(dto.Languages))+")")
+		for _, language := range dto.Languages {
+			args = append(args, language)
+		}
+	}
+	if !dto.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, dto.From)
+	}
+	if !dto.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, dto.To)
+	}
+
+	query := "SELECT id, problem_id, language, result, used_time, used_memory FROM submit"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id;"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	submits := make([]GetSubmitDAO, 0)
+	for rows.Next() {
+		var submit GetSubmitDAO
+		if err = rows.Scan(&submit.SubmitId, &submit.ProblemId, &submit.Language, &submit.Result, &submit.UsedTime, &submit.UsedMemory); err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		submits = append(submits, submit)
+	}
+	if err = rows.Err(); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return submits, nil
+}
+
+func (repository *SubmitRepository) SaveSubmitResult(dto SaveSubmitResultDTO) error {
+	defer metrics.ObserveDatabase("save_submit_result")()
+
+	db := repository.dataSource.GetDatabase()
+
+	query := "UPDATE submit SET result = ?, used_time = ?, used_memory = ? WHERE id = ?;"
+	if _, err := db.Exec(query, dto.Result, dto.UsedTime, dto.UsedMemory, dto.SubmitId); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// saveCode 가 저장한 제출 코드를 가져온다
+func (repository *SubmitRepository) GetCode(submitId int, language string) ([]byte, error) {
+	os := repository.dataSource.GetObjectStorage()
+
+	path := filepath.Join("submits", strconv.Itoa(submitId), "Main."+FileExtension(language))
+	code, err := os.GetObject(path)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return DecodeBase64(code), nil
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/gofiber/fiber/v2/middleware/healthcheck"
+	. "leita/src/entities"
+	"leita/src/handlers"
+	"leita/src/middlewares"
+)
+
+// RegisterGatewayRoutes 는 라우터 모드(JUDGE_MODE=router) 에서 채점 대신 노드로 요청을 보내는 경로를 등록한다.
+// 라우터는 데이터베이스와 오브젝트 스토리지에 연결하지 않는다.
+// 요청 하나로 끝나지 않고 특정 노드의 상태를 따라가야 하는 경로는 노드로 보내지 않고 501 로 거절하므로 노드에 직접 요청해야 한다
+func RegisterGatewayRoutes(app *fiber.App, api fiber.Router, authenticator *middlewares.Authenticator) error {
+	handler, err := handlers.NewGatewayHandler()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	runQuota, err := middlewares.GetRunQuota()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	app.Use(healthcheck.New(healthcheck.Config{
+		ReadinessProbe: handler.ReadinessProbe(),
+	}))
+
+	problemGroup := api.Group("/problem")
+	problemGroup.Post("/submit/:problemId", authenticator.RequireScope(ScopeSubmit), handler.SubmitProblem())
+	problemGroup.Post("/run/:problemId", authenticator.RequireScope(ScopeRun), runQuota.KeyRateLimit(), runQuota.UserRateLimit(), runQuota.LimitConcurrency(), handler.RunProblem())
+
+	api.Get("/languages", handler.GetLanguages())
+
+	notRoutable := []string{
+		"/problem/ws",
+		"/problem/submit/:submitId/events",
+		"/problem/stress",
+		"/problem/hack",
+		"/problem/:problemId/package",
+		"/problem/:problemId/validate",
+		"/problem/:problemId/validator",
+		"/problem/:problemId/validation",
+		"/problem/:problemId/generation",
+		"/problem/:problemId/generate",
+		"/problem/:problemId/solutions",
+		"/problem/:problemId/verify",
+		"/problem/:problemId/verify/:jobId",
+		"/rejudge",
+		"/rejudge/*",
+		"/queue/*",
+	}
+	for _, path := range notRoutable {
+		api.All(path, handler.NotRoutable())
+	}
+
+	nodeGroup := api.Group("/nodes", authenticator.RequireScope(ScopeAdmin))
+	nodeGroup.Get("/", handler.GetNodes())
+	nodeGroup.Post("/", handler.RegisterNode())
+	nodeGroup.Delete("/", handler.UnregisterNode())
+
+	return nil
+}
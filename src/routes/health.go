@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/gofiber/fiber/v2/middleware/healthcheck"
+	"leita/src/handlers"
+)
+
+// RegisterHealthRoutes 는 /livez, /readyz 와 확인 항목별 결과를 보여 주는 /readyz/details 를 등록한다
+func RegisterHealthRoutes(app *fiber.App) error {
+	handler, err := handlers.NewHealthHandler()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	app.Use(healthcheck.New(healthcheck.Config{
+		ReadinessProbe: handler.ReadinessProbe(),
+	}))
+	app.Get("/readyz/details", handler.Readiness())
+
+	return nil
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"leita/src/handlers"
+	"leita/src/middlewares"
+)
+
+func RegisterLanguageRoutes(api fiber.Router, _ *middlewares.Authenticator) error {
+	handler, err := handlers.NewLanguageHandler()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	api.Get("/languages", handler.GetLanguages())
+
+	return nil
+}
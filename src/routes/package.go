@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/entities"
+	"leita/src/handlers"
+	"leita/src/middlewares"
+)
+
+func RegisterPackageRoutes(api fiber.Router, authenticator *middlewares.Authenticator) error {
+	handler, err := handlers.NewPackageHandler()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	validationHandler, err := handlers.NewValidationHandler()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	generationHandler, err := handlers.NewGenerationHandler()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	verificationHandler, err := handlers.NewVerificationHandler()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	requireAdmin := authenticator.RequireScope(ScopeAdmin)
+	api.Post("/problem/:problemId/package", requireAdmin, handler.ImportPackage())
+	api.Post("/problem/:problemId/validate", requireAdmin, validationHandler.Validate())
+	api.Put("/problem/:problemId/validator", requireAdmin, validationHandler.SetValidator())
+	api.Get("/problem/:problemId/validation", requireAdmin, validationHandler.GetValidation())
+	api.Put("/problem/:problemId/generation", requireAdmin, generationHandler.SetGeneration())
+	api.Post("/problem/:problemId/generate", requireAdmin, generationHandler.Generate())
+	api.Post("/problem/:problemId/solutions", requireAdmin, verificationHandler.AddSolution())
+	api.Post("/problem/:problemId/verify", requireAdmin, verificationHandler.Verify())
+	api.Get("/problem/:problemId/verify/:jobId", requireAdmin, verificationHandler.GetVerification())
+
+	return nil
+}
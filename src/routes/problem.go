@@ -3,19 +3,43 @@ package routes
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/entities"
 	"leita/src/handlers"
+	"leita/src/middlewares"
 )
 
-func RegisterProblemRoutes(api fiber.Router) error {
+func RegisterProblemRoutes(api fiber.Router, authenticator *middlewares.Authenticator) error {
 	handler, err := handlers.NewProblemHandler()
 	if err != nil {
 		log.Error(err)
 		return err
 	}
 
+	stressHandler, err := handlers.NewStressHandler()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	hackHandler, err := handlers.NewHackHandler()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	runQuota, err := middlewares.GetRunQuota()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
 	problemGroup := api.Group("/problem")
-	problemGroup.Post("/submit/:problemId", handler.SubmitProblem())
-	problemGroup.Post("/run/:problemId", handler.RunProblem())
+	problemGroup.Post("/submit/:problemId", authenticator.RequireScope(ScopeSubmit), handler.SubmitProblem())
+	problemGroup.Get("/submit/:submitId/events", authenticator.RequireScope(ScopeSubmit), handler.SubmitEvents())
+	problemGroup.Post("/run/:problemId", authenticator.RequireScope(ScopeRun), runQuota.KeyRateLimit(), runQuota.UserRateLimit(), runQuota.LimitConcurrency(), handler.RunProblem())
+	problemGroup.Post("/stress", authenticator.RequireScope(ScopeRun), runQuota.KeyRateLimit(), runQuota.UserRateLimit(), runQuota.LimitConcurrency(), stressHandler.StressTest())
+	problemGroup.Post("/hack", authenticator.RequireScope(ScopeSubmit), runQuota.KeyRateLimit(), runQuota.UserRateLimit(), runQuota.LimitConcurrency(), hackHandler.Hack())
+	problemGroup.Get("/ws", authenticator.RequireScope(ScopeSubmit, ScopeRun), runQuota.KeyRateLimit(), runQuota.UserRateLimit(), runQuota.IdentifyUser(), handler.JudgeSocket())
 
 	return nil
 }
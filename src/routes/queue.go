@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/entities"
+	"leita/src/handlers"
+	"leita/src/middlewares"
+	"leita/src/services"
+	. "leita/src/utils"
+)
+
+// RegisterQueueRoutes 는 JUDGE_QUEUE_BACKEND 가 있을 때 큐 API 를 열고, JUDGE_MODE=worker 면 워커를 시작한다
+func RegisterQueueRoutes(api fiber.Router, authenticator *middlewares.Authenticator) error {
+	if GetEnv("JUDGE_QUEUE_BACKEND") == "" && GetEnv("JUDGE_MODE") != "worker" {
+		return nil
+	}
+
+	handler, err := handlers.NewQueueHandler()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	queueGroup := api.Group("/queue", authenticator.RequireScope(ScopeSubmit))
+	queueGroup.Post("/submit/:problemId", handler.EnqueueSubmit())
+	queueGroup.Get("/:jobId", handler.GetJob())
+
+	if GetEnv("JUDGE_MODE") == "worker" {
+		service, err := services.NewWorkerService()
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+		service.Start()
+	}
+
+	return nil
+}
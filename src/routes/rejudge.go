@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/entities"
+	"leita/src/handlers"
+	"leita/src/middlewares"
+)
+
+func RegisterRejudgeRoutes(api fiber.Router, authenticator *middlewares.Authenticator) error {
+	handler, err := handlers.NewRejudgeHandler()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	rejudgeGroup := api.Group("/rejudge", authenticator.RequireScope(ScopeAdmin))
+	rejudgeGroup.Post("/", handler.RejudgeSubmits())
+	rejudgeGroup.Post("/submit/:submitId", handler.RejudgeSubmit())
+	rejudgeGroup.Post("/problem/:problemId", handler.RejudgeProblem())
+	rejudgeGroup.Get("/:jobId", handler.GetRejudge())
+
+	return nil
+}
@@ -3,12 +3,54 @@ package routes
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
+	"leita/src/metrics"
+	"leita/src/middlewares"
+	. "leita/src/utils"
 )
 
 func RegisterRoutes(app *fiber.App) error {
-	api := app.Group("/api")
+	authenticator, err := middlewares.NewAuthenticator()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	app.Get("/metrics", metrics.Handler())
+
+	// 인증에 실패한 요청도 트레이스에 남도록 Trace 를 먼저 건다
+	api := app.Group("/api", middlewares.Trace(), authenticator.Authenticate())
+
+	// 라우터 모드는 직접 채점하지 않고 언어를 지원하는 노드로 보낸다
+	if GetEnv("JUDGE_MODE") == "router" {
+		return RegisterGatewayRoutes(app, api, authenticator)
+	}
+
+	if err := RegisterHealthRoutes(app); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if err := RegisterProblemRoutes(api, authenticator); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if err := RegisterRejudgeRoutes(api, authenticator); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if err := RegisterLanguageRoutes(api, authenticator); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if err := RegisterPackageRoutes(api, authenticator); err != nil {
+		log.Error(err)
+		return err
+	}
 
-	if err := RegisterProblemRoutes(api); err != nil {
+	if err := RegisterQueueRoutes(api, authenticator); err != nil {
 		log.Error(err)
 		return err
 	}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/entities"
+	"leita/src/metrics"
+	"leita/src/repositories"
+	. "leita/src/utils"
+)
+
+const (
+	defaultCallbackMaxAttempts = 6
+	defaultCallbackBackoff     = time.Second
+	defaultCallbackMaxBackoff  = 5 * time.Minute
+	defaultCallbackTimeout     = 10 * time.Second
+)
+
+var (
+	ErrCallbackNotConfigured  = errors.New("callback is not configured: JUDGE_CALLBACK_SECRET and JUDGE_CALLBACK_ALLOWED_HOSTS are required")
+	ErrCallbackHostNotAllowed = errors.New("callback host is not allowed")
+)
+
+// 종료할 때 다시 보내기를 기다리는 콜백은 dead letter 로 남긴다
+var (
+	callbackGroup    sync.WaitGroup
+	callbackStopOnce sync.Once
+	callbackStop     = make(chan struct{})
+)
+
+// CallbackService 는 채점 결과를 제출에 적힌 callbackUrl 로 POST 한다.
+// 본문은 JUDGE_CALLBACK_SECRET 으로 서명하고, 실패하면 지수 백오프로 JUDGE_CALLBACK_MAX_ATTEMPTS 번까지 다시 보낸 뒤
+// 끝내 전달하지 못한 콜백은 callback_dead_letter 테이블에 남긴다.
+//
+// 콜백은 JUDGE_CALLBACK_ALLOWED_HOSTS 에 적힌 호스트로만 보낸다. "*.example.com" 처럼 적으면 하위 도메인을 모두 허용한다.
+//
+//   - X-Delivery-Id: 제출이나 채점 작업에서 정해지므로 다시 보내거나 같은 작업을 다시 채점해도 같은 값이고, 받는 쪽에서 중복을 거를 수 있다
+//   - X-Timestamp: {unix seconds}
+//   - X-Signature: hex(HMAC-SHA256(secret, timestamp + "\n" + body))
+type CallbackService struct {
+	repository   *repositories.CallbackRepository
+	secret       string
+	allowedHosts []string
+	maxAttempts  int
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	client       *http.Client
+}
+
+func NewCallbackService() (*CallbackService, error) {
+	repository, err := repositories.NewCallbackRepository()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	maxAttempts, err := parseIntEnv("JUDGE_CALLBACK_MAX_ATTEMPTS", defaultCallbackMaxAttempts)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	backoff, err := parseDurationEnv("JUDGE_CALLBACK_BACKOFF", defaultCallbackBackoff)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	maxBackoff, err := parseDurationEnv("JUDGE_CALLBACK_MAX_BACKOFF", defaultCallbackMaxBackoff)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	timeout, err := parseDurationEnv("JUDGE_CALLBACK_TIMEOUT", defaultCallbackTimeout)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	var allowedHosts []string
+	for _, host := range strings.Split(GetEnv("JUDGE_CALLBACK_ALLOWED_HOSTS"), ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			allowedHosts = append(allowedHosts, host)
+		}
+	}
+
+	return &CallbackService{
+		repository:   repository,
+		secret:       GetEnv("JUDGE_CALLBACK_SECRET"),
+		allowedHosts: allowedHosts,
+		maxAttempts:  maxAttempts,
+		backoff:      backoff,
+		maxBackoff:   maxBackoff,
+		client: &http.Client{
+			Timeout: timeout,
+			// 리다이렉트를 따라가면 허용하지 않은 호스트로 보낼 수 있다
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}, nil
+}
+
+// Validate 는 콜백을 받을 수 있는 URL 인지, 허용한 호스트인지와 서명할 비밀 키가 있는지 확인한다
+func (service *CallbackService) Validate(callbackUrl string) error {
+	if service.secret == "" || len(service.allowedHosts) == 0 {
+		return ErrCallbackNotConfigured
+	}
+
+	parsed, err := url.Parse(callbackUrl)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return fmt.Errorf("invalid callback url: %s", callbackUrl)
+	}
+
+	if !service.isAllowedHost(strings.ToLower(parsed.Hostname())) {
+		return fmt.Errorf("%w: %s", ErrCallbackHostNotAllowed, parsed.Hostname())
+	}
+
+	return nil
+}
+
+func (service *CallbackService) isAllowedHost(host string) bool {
+	for _, allowed := range service.allowedHosts {
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok {
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return true
+			}
+			continue
+		}
+		if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// NewCallbackPayload 는 채점 결과로 콜백 본문을 만든다.
+// 종료 중이라 채점을 끝내지 못했으면 최종 결과가 아니므로 Retryable 을 켜서 다시 제출하도록 알린다
+func NewCallbackPayload(dto SubmitProblemDTO, result JudgeResultEnum, usedTime, usedMemory int64, err error) CallbackPayload {
+	return CallbackPayload{
+		SubmitId:   dto.SubmitId,
+		ProblemId:  dto.ProblemId,
+		Language:   dto.Language,
+		Result:     result.String(),
+		Error:      ErrStrIfNotNil(err),
+		Retryable:  errors.Is(err, ErrDraining),
+		UsedTime:   usedTime,
+		UsedMemory: usedMemory,
+		JudgedAt:   time.Now(),
+	}
+}
+
+// SubmitDeliveryId, JobDeliveryId 는 제출과 채점 작업의 콜백 X-Delivery-Id 를 만든다
+func SubmitDeliveryId(submitId int) string {
+	return "submit-" + strconv.Itoa(submitId)
+}
+
+func JobDeliveryId(jobId int64) string {
+	return "job-" + strconv.FormatInt(jobId, 10)
+}
+
+// Deliver 는 백그라운드에서 judge 를 실행하고 그 결과를 deliveryId 로 콜백을 보낸다.
+// 다시 제출하라는 Retryable 결과는 이어서 보낼 최종 결과와 구분되도록 deliveryId 를 따로 만든다.
+// 채점이 끝나기 전에 종료가 시작되어도 결과를 보내거나 dead letter 로 남길 때까지 StopCallbacks 가 기다린다
+func (service *CallbackService) Deliver(deliveryId, callbackUrl string, judge func() CallbackPayload) {
+	callbackGroup.Add(1)
+	go func() {
+		defer callbackGroup.Done()
+
+		payload := judge()
+		body, err := json.Marshal(payload)
+		if err != nil {
+			log.Error(err)
+			return
+		}
+
+		if payload.Retryable {
+			deliveryId = fmt.Sprintf("%s-retryable-%d", deliveryId, payload.JudgedAt.UnixNano())
+		}
+		service.deliver(deliveryId, callbackUrl, payload.SubmitId, body)
+	}()
+}
+
+func (service *CallbackService) deliver(deliveryId, callbackUrl string, submitId int, body []byte) {
+	var err error
+	attempts := 0
+	for attempts < service.maxAttempts {
+		if attempts > 0 {
+			select {
+			case <-time.After(service.backoffDelay(attempts)):
+			case <-callbackStop:
+				service.deadLetter(deliveryId, callbackUrl, submitId, body, attempts, fmt.Errorf("%w, last error: %v", ErrDraining, err))
+				return
+			}
+		}
+		attempts++
+
+		var retryable bool
+		retryable, err = service.post(deliveryId, callbackUrl, body)
+		if err == nil {
+			metrics.CallbackDeliveries.WithLabelValues("delivered").Inc()
+			log.Infow("콜백 전달 완료", "submitId", submitId, "deliveryId", deliveryId, "attempts", attempts)
+			return
+		}
+
+		log.Warnw("콜백 전달 실패", "submitId", submitId, "deliveryId", deliveryId, "attempts", attempts, "error", err)
+		if !retryable {
+			break
+		}
+		metrics.CallbackDeliveries.WithLabelValues("retried").Inc()
+	}
+
+	service.deadLetter(deliveryId, callbackUrl, submitId, body, attempts, err)
+}
+
+// 지연 시간은 backoff * 2^(attempts-1) 에서 maxBackoff 까지 늘어나고, 동시에 실패한 콜백이 한꺼번에 몰리지 않도록 ±20% 를 흔든다
+func (service *CallbackService) backoffDelay(attempts int) time.Duration {
+	delay := service.maxBackoff
+	if attempts-1 < 32 {
+		delay = min(service.backoff<<(attempts-1), service.maxBackoff)
+	}
+
+	jitter := 0.8 + rand.Float64()*0.4
+	return time.Duration(float64(delay) * jitter)
+}
+
+// 받는 쪽이 요청 자체를 거절한 4xx 는 다시 보내도 같은 결과이므로 다시 보내지 않는다
+func (service *CallbackService) post(deliveryId, callbackUrl string, body []byte) (bool, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(service.secret))
+	mac.Write([]byte(timestamp + "\n"))
+	mac.Write(body)
+
+	request, err := http.NewRequest(http.MethodPost, callbackUrl, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Delivery-Id", deliveryId)
+	request.Header.Set("X-Timestamp", timestamp)
+	request.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+
+	response, err := service.client.Do(request)
+	if err != nil {
+		return true, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+
+	err = fmt.Errorf("callback returned %d", response.StatusCode)
+	retryable := response.StatusCode >= 500 ||
+		response.StatusCode == http.StatusRequestTimeout ||
+		response.StatusCode == http.StatusTooManyRequests
+	return retryable, err
+}
+
+func (service *CallbackService) deadLetter(deliveryId, callbackUrl string, submitId int, body []byte, attempts int, err error) {
+	metrics.CallbackDeliveries.WithLabelValues("dead_letter").Inc()
+	log.Errorw("콜백을 전달하지 못해 dead letter 로 남깁니다", "submitId", submitId, "deliveryId", deliveryId, "attempts", attempts, "error", err)
+
+	saveDeadLetterDTO := SaveDeadLetterDTO{
+		DeliveryId:  deliveryId,
+		SubmitId:    submitId,
+		CallbackUrl: callbackUrl,
+		Payload:     body,
+		Attempts:    attempts,
+		LastError:   ErrStrIfNotNil(err),
+	}
+	if err := service.repository.SaveDeadLetter(saveDeadLetterDTO); err != nil {
+		log.Error(err)
+	}
+}
+
+// StopCallbacks 는 다시 보내기를 기다리는 콜백을 dead letter 로 남기고, 보내는 중인 콜백이 끝나기를 ctx 가 끝날 때까지 기다린다
+func StopCallbacks(ctx context.Context) error {
+	callbackStopOnce.Do(func() {
+		close(callbackStop)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		callbackGroup.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
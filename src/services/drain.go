@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// 강제로 종료한 채점이 정리를 마칠 때까지 기다리는 시간
+const killGracePeriod = 5 * time.Second
+
+var ErrDraining = errors.New("server is shutting down")
+
+// 진행 중인 채점을 추적해서, 종료할 때 새 채점은 받지 않고 진행 중인 채점이 끝나기를 기다린다
+var (
+	drainMutex sync.RWMutex
+	draining   bool
+	inFlight   sync.WaitGroup
+	// 채점 중인 작업 디렉터리, 강제로 종료한 채점의 디렉터리는 지운다
+	workspaces sync.Map
+
+	// 취소되면 실행 중인 컴파일러와 제출 프로그램이 모두 종료된다
+	judgeContext, killJudges = context.WithCancel(context.Background())
+)
+
+func beginJudge(workspace string) error {
+	drainMutex.RLock()
+	defer drainMutex.RUnlock()
+
+	if draining {
+		return ErrDraining
+	}
+
+	inFlight.Add(1)
+	workspaces.Store(workspace, struct{}{})
+	return nil
+}
+
+func endJudge(workspace string) {
+	if isKilled() {
+		if err := os.RemoveAll(workspace); err != nil {
+			log.Error(err)
+		}
+	}
+
+	workspaces.Delete(workspace)
+	inFlight.Done()
+}
+
+func IsDraining() bool {
+	drainMutex.RLock()
+	defer drainMutex.RUnlock()
+
+	return draining
+}
+
+// Drain 은 새 채점을 막고 진행 중인 채점이 끝나기를 ctx 가 끝날 때까지 기다린다.
+// 그때까지 끝나지 않은 채점은 자식 프로세스를 종료하고 작업 디렉터리를 지운다
+func Drain(ctx context.Context) error {
+	drainMutex.Lock()
+	draining = true
+	drainMutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Info("진행 중인 채점이 모두 끝났습니다")
+		return nil
+	case <-ctx.Done():
+	}
+
+	log.Warn("종료 기한이 지나 진행 중인 채점을 강제로 종료합니다")
+	killJudges()
+
+	select {
+	case <-done:
+	case <-time.After(killGracePeriod):
+		log.Error("강제로 종료한 채점이 정리되지 않았습니다")
+	}
+
+	workspaces.Range(func(key, _ any) bool {
+		workspace := key.(string)
+		log.Warn("작업 디렉터리 삭제: ", workspace)
+		if err := os.RemoveAll(workspace); err != nil {
+			log.Error(err)
+		}
+		return true
+	})
+
+	return ctx.Err()
+}
+
+// 종료 중에 강제로 멈춘 것이라면 채점 결과 대신 ErrDraining 을 돌려주기 위해 쓴다
+func isKilled() bool {
+	return judgeContext.Err() != nil
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/entities"
+)
+
+const (
+	// 채점이 끝난 뒤에도 늦게 구독한 클라이언트가 받을 수 있도록 이벤트를 남겨두는 시간
+	judgeEventRetention = time.Minute
+	// 결과 이벤트 없이 멈춘 토픽을 지우기까지 기다리는 시간
+	judgeEventStaleTimeout = time.Hour
+	// 구독자 채널 버퍼, 느린 클라이언트 때문에 채점이 멈추지 않도록 넘치면 버린다
+	judgeEventBufferSize = 256
+)
+
+type JudgeEventBroker struct {
+	mutex  sync.Mutex
+	topics map[string]*judgeEventTopic
+}
+
+type judgeEventTopic struct {
+	events      []JudgeEvent
+	subscribers map[chan JudgeEvent]struct{}
+	finished    bool
+	updatedAt   time.Time
+}
+
+var (
+	judgeEventBroker     *JudgeEventBroker
+	judgeEventBrokerOnce sync.Once
+)
+
+func GetJudgeEventBroker() *JudgeEventBroker {
+	judgeEventBrokerOnce.Do(func() {
+		judgeEventBroker = &JudgeEventBroker{
+			topics: make(map[string]*judgeEventTopic),
+		}
+	})
+
+	return judgeEventBroker
+}
+
+// Subscribe 는 지금까지 발행된 이벤트를 먼저 보내고, 이후 이벤트를 이어서 보낸다.
+// 결과 이벤트가 발행되면 채널이 닫힌다.
+func (broker *JudgeEventBroker) Subscribe(judgeType string, submitId int) (<-chan JudgeEvent, func()) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	broker.clean()
+	topic := broker.topic(judgeEventKey(judgeType, submitId))
+
+	events := make(chan JudgeEvent, len(topic.events)+judgeEventBufferSize)
+	for _, event := range topic.events {
+		events <- event
+	}
+
+	if topic.finished {
+		close(events)
+		return events, func() {}
+	}
+
+	topic.subscribers[events] = struct{}{}
+	unsubscribe := func() {
+		broker.mutex.Lock()
+		defer broker.mutex.Unlock()
+
+		if _, exists := topic.subscribers[events]; exists {
+			delete(topic.subscribers, events)
+			close(events)
+		}
+	}
+
+	return events, unsubscribe
+}
+
+func (broker *JudgeEventBroker) Publish(judgeType string, submitId int, eventType JudgeEventEnum, event JudgeEvent) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	broker.publish(judgeType, submitId, eventType, event)
+}
+
+// PublishResultOnce 는 결과 이벤트가 아직 남아 있지 않을 때만 결과 이벤트를 발행한다
+func (broker *JudgeEventBroker) PublishResultOnce(judgeType string, submitId int, event JudgeEvent) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	broker.clean()
+	if topic, exists := broker.topics[judgeEventKey(judgeType, submitId)]; exists && topic.finished {
+		return
+	}
+
+	broker.publish(judgeType, submitId, JudgeEventResult, event)
+}
+
+// Reset 은 같은 submitId 를 다시 채점하기 전에 지난 채점의 이벤트를 지워서, 새로 구독한 클라이언트가 지난 결과를 받지 않게 한다.
+// 결과를 기다리며 먼저 구독한 클라이언트는 그대로 두고 새 채점의 이벤트를 받게 한다
+func (broker *JudgeEventBroker) Reset(judgeType string, submitId int) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	key := judgeEventKey(judgeType, submitId)
+	topic, exists := broker.topics[key]
+	if !exists {
+		return
+	}
+
+	if topic.finished {
+		delete(broker.topics, key)
+		return
+	}
+
+	topic.events = make([]JudgeEvent, 0)
+	topic.updatedAt = time.Now()
+}
+
+// publish 는 mutex 를 잡은 채로 호출해야 한다
+func (broker *JudgeEventBroker) publish(judgeType string, submitId int, eventType JudgeEventEnum, event JudgeEvent) {
+	broker.clean()
+	topic := broker.topic(judgeEventKey(judgeType, submitId))
+
+	event.Type = eventType.String()
+	event.SubmitId = submitId
+	topic.events = append(topic.events, event)
+	topic.updatedAt = time.Now()
+
+	for subscriber := range topic.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			log.Warn("이벤트 버퍼가 가득 차 이벤트를 버립니다: ", judgeType, "/", submitId)
+		}
+	}
+
+	if eventType == JudgeEventResult {
+		topic.finished = true
+		for subscriber := range topic.subscribers {
+			close(subscriber)
+		}
+		topic.subscribers = make(map[chan JudgeEvent]struct{})
+	}
+}
+
+// topic 은 mutex 를 잡은 채로 호출해야 한다
+func (broker *JudgeEventBroker) topic(key string) *judgeEventTopic {
+	topic, exists := broker.topics[key]
+	if !exists {
+		topic = &judgeEventTopic{
+			events:      make([]JudgeEvent, 0),
+			subscribers: make(map[chan JudgeEvent]struct{}),
+			updatedAt:   time.Now(),
+		}
+		broker.topics[key] = topic
+	}
+
+	return topic
+}
+
+// clean 은 끝났거나 구독자 없이 방치된 오래된 토픽을 지운다. mutex 를 잡은 채로 호출해야 한다
+func (broker *JudgeEventBroker) clean() {
+	for key, topic := range broker.topics {
+		if len(topic.subscribers) > 0 {
+			continue
+		}
+
+		idle := time.Since(topic.updatedAt)
+		done := topic.finished || len(topic.events) == 0
+		if (done && idle > judgeEventRetention) || idle > judgeEventStaleTimeout {
+			delete(broker.topics, key)
+		}
+	}
+}
+
+func judgeEventKey(judgeType string, submitId int) string {
+	return judgeType + "/" + strconv.Itoa(submitId)
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"os/exec"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"github.com/gofiber/fiber/v2/log"
+	"leita/src/metrics"
+	. "leita/src/utils"
+)
+
+// 모든 제출이 함께 쓰는 프로그램 실행 슬롯, 슬롯마다 서로 다른 CPU 코어 하나가 배정된다
+var (
+	executionSlots     chan int
+	executionSlotsOnce sync.Once
+)
+
+func getExecutionSlots() chan int {
+	executionSlotsOnce.Do(func() {
+		cpus := AvailableCPUs()
+
+		workers := len(cpus)
+		if value := GetEnv("JUDGE_MAX_WORKERS"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed <= 0 {
+				log.Error("invalid JUDGE_MAX_WORKERS: ", value)
+			} else {
+				workers = parsed
+			}
+		}
+
+		// 한 코어에 슬롯 두 개가 배정되면 같은 코어에서 도는 프로그램끼리 시간이 섞인다
+		if workers > len(cpus) {
+			log.Warn("JUDGE_MAX_WORKERS 가 사용할 수 있는 코어 수보다 많아 ", len(cpus), " 개로 줄입니다")
+			workers = len(cpus)
+		}
+
+		executionSlots = make(chan int, workers)
+		for i := 0; i < workers; i++ {
+			executionSlots <- cpus[i]
+		}
+	})
+
+	return executionSlots
+}
+
+func acquireExecutionSlot() int {
+	metrics.QueueDepth.Inc()
+	cpu := <-getExecutionSlots()
+	metrics.QueueDepth.Dec()
+	metrics.ActiveWorkers.Inc()
+
+	return cpu
+}
+
+func releaseExecutionSlot(cpu int) {
+	metrics.ActiveWorkers.Dec()
+	getExecutionSlots() <- cpu
+}
+
+// 테스트 케이스를 동시에 채점할 때는 배정받은 코어에 고정한 채로 프로그램을 실행한다.
+// 하나씩 채점할 때는 JVM, Go 처럼 여러 스레드를 쓰는 런타임이 한 코어에 묶이지 않도록 고정하지 않는다
+func startProgram(cmd *exec.Cmd, cpu int) error {
+	if !isParallelJudge() {
+		if err := cmd.Start(); err != nil {
+			log.Error(err)
+			return err
+		}
+		return nil
+	}
+
+	if err := StartPinned(cmd, cpu); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// 프로그램 출력이 디스크를 채우지 않도록 표준 출력은 JUDGE_OUTPUT_LIMIT(MB) 를 넘으면 프로그램을 멈추고,
+// 표준 에러는 검증기 메시지처럼 앞부분만 쓰므로 stderrLimit 까지만 남긴다
+const (
+	defaultOutputLimit = 256 // MB
+	stderrLimit        = 64 * 1024
+)
+
+func outputLimit() int64 {
+	limit, err := parseIntEnv("JUDGE_OUTPUT_LIMIT", defaultOutputLimit)
+	if err != nil {
+		log.Error(err)
+		limit = defaultOutputLimit
+	}
+
+	return int64(limit) << 20
+}
+
+func isParallelJudge() bool {
+	parallel, _ := strconv.ParseBool(GetEnv("JUDGE_PARALLEL_TESTCASES"))
+	return parallel && runtime.NumCPU() > 1
+}
@@ -0,0 +1,423 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.opentelemetry.io/otel/attribute"
+	. "leita/src/entities"
+	"leita/src/tracing"
+	. "leita/src/utils"
+)
+
+// 생성기나 모범 풀이가 테스트 하나를 만드는 데 쓸 수 있는 시간
+const defaultGeneratorTimeLimit = 10 * time.Second
+
+var (
+	ErrInvalidGeneration = errors.New("invalid test generation")
+
+	generatorNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	// 같은 문제의 테스트를 동시에 만들면 작업 디렉터리가 겹치므로 문제마다 하나씩만 만든다
+	generationLocks sync.Map
+)
+
+// GenerationService 는 생성 스크립트대로 생성기를 실행해 테스트 입력을 만들고,
+// main 태그가 붙은 풀이를 실행해 정답을 만든 뒤 문제의 테스트 케이스를 모두 바꾼다
+type GenerationService struct {
+	problemService    *ProblemService
+	validationService *ValidationService
+	timeLimit         time.Duration
+}
+
+// 생성 스크립트의 한 줄로 만드는 테스트
+type generationStep struct {
+	test      int
+	generator string
+	args      []string
+	command   string
+}
+
+func NewGenerationService() (*GenerationService, error) {
+	validationService, err := NewValidationService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	timeLimit, err := parseDurationEnv("JUDGE_GENERATOR_TIME_LIMIT", defaultGeneratorTimeLimit)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &GenerationService{
+		problemService:    validationService.problemService,
+		validationService: validationService,
+		timeLimit:         timeLimit,
+	}, nil
+}
+
+// SetGeneration 은 생성기와 생성 스크립트를 바꾸고, 모범 풀이가 있으면 main 풀이도 바꾼다.
+// 테스트는 Generate 를 호출해야 만든다
+func (service *GenerationService) SetGeneration(dto SetGenerationDTO) error {
+	names := make(map[string]bool)
+	for _, generator := range dto.Generators {
+		if !generatorNamePattern.MatchString(generator.Name) {
+			return fmt.Errorf("%w: generator name %q", ErrInvalidGeneration, generator.Name)
+		}
+		if names[generator.Name] {
+			return fmt.Errorf("%w: duplicate generator %q", ErrInvalidGeneration, generator.Name)
+		}
+		names[generator.Name] = true
+	}
+
+	if _, err := parseGenerationScript(dto.Script, names); err != nil {
+		return err
+	}
+
+	repository := service.problemService.repository
+
+	manifest, err := repository.GetManifest(dto.ProblemId)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if manifest == nil {
+		manifest = &ProblemManifest{ProblemId: dto.ProblemId}
+	}
+
+	generators := make([]PackageFile, 0, len(dto.Generators))
+	for _, generator := range dto.Generators {
+		file := PackageFile{
+			Path:     ProblemObjectPath(dto.ProblemId, filepath.Join("generators", generator.Name+"."+FileExtension(generator.Language))),
+			Language: generator.Language,
+			Name:     generator.Name,
+		}
+		if err = repository.SaveCode(file.Path, EncodeBase64(generator.Code)); err != nil {
+			log.Error(err)
+			return err
+		}
+		generators = append(generators, file)
+	}
+
+	script := ProblemObjectPath(dto.ProblemId, filepath.Join("generators", "script.txt"))
+	if err = repository.SaveCode(script, EncodeBase64([]byte(dto.Script))); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if dto.Solution != nil {
+		solution := PackageSolution{
+			PackageFile: PackageFile{
+				Path:     ProblemObjectPath(dto.ProblemId, filepath.Join("solutions", MainSolutionTag, "Main."+FileExtension(dto.Solution.Language))),
+				Language: dto.Solution.Language,
+			},
+			Tag: MainSolutionTag,
+		}
+		if err = repository.SaveCode(solution.Path, EncodeBase64(dto.Solution.Code)); err != nil {
+			log.Error(err)
+			return err
+		}
+
+		solutions := []PackageSolution{solution}
+		for _, existing := range manifest.Solutions {
+			if existing.Tag != MainSolutionTag {
+				solutions = append(solutions, existing)
+			}
+		}
+		manifest.Solutions = solutions
+	}
+
+	manifest.Generators = generators
+	manifest.Script = script
+	if err = repository.SaveManifest(*manifest); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// Generate 는 테스트를 모두 만들고 검증기로 입력을 검사한 뒤에만 문제의 테스트 케이스를 바꾸고, 검증 결과를 새 버전으로 남긴다.
+// 생성기, 검증기나 모범 풀이가 실패하면 테스트 케이스는 그대로 두고 response.Error 에 이유를 남긴다
+func (service *GenerationService) Generate(ctx context.Context, problemId int) (GenerateTestsResponse, error) {
+	lock, _ := generationLocks.LoadOrStore(problemId, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	ctx, span := tracing.Start(ctx, "GenerateTests", attribute.Int("judge.problem_id", problemId))
+	response, err := service.generate(ctx, problemId)
+	span.SetAttributes(attribute.Int("judge.testcase_num", response.TestCaseNum))
+	tracing.End(span, err)
+	if err != nil {
+		log.WithContext(ctx).Error(err)
+		return GenerateTestsResponse{}, err
+	}
+	if response.Error != "" {
+		log.WithContext(ctx).Infow("테스트 생성 실패", "problemId", problemId, "error", response.Error)
+		return response, nil
+	}
+
+	log.WithContext(ctx).Infow("테스트 생성 완료", "problemId", problemId, "testCaseNum", response.TestCaseNum)
+	return response, nil
+}
+
+// 생성기를 하나씩 빌드해서 그 생성기를 쓰는 줄을 모두 실행한 뒤, 모범 풀이를 빌드해서 모든 입력의 정답을 만든다.
+// 빌드 결과물이 같은 작업 디렉터리를 쓰므로 한 번에 프로그램 하나만 빌드한다
+func (service *GenerationService) generate(ctx context.Context, problemId int) (GenerateTestsResponse, error) {
+	repository := service.problemService.repository
+
+	response := GenerateTestsResponse{
+		ProblemId: problemId,
+		Tests:     make([]GeneratedTest, 0),
+	}
+
+	manifest, err := repository.GetManifest(problemId)
+	if err != nil {
+		return GenerateTestsResponse{}, err
+	}
+	if manifest == nil || manifest.Script == "" {
+		response.Error = "no generators are set for this problem"
+		return response, nil
+	}
+
+	var solution *PackageSolution
+	for i := range manifest.Solutions {
+		if manifest.Solutions[i].Tag == MainSolutionTag {
+			solution = &manifest.Solutions[i]
+			break
+		}
+	}
+	if solution == nil {
+		response.Error = "no main solution is set for this problem"
+		return response, nil
+	}
+
+	generators := make(map[string]bool)
+	for _, generator := range manifest.Generators {
+		generators[generator.Name] = true
+	}
+
+	script, err := repository.GetObject(manifest.Script)
+	if err != nil {
+		return GenerateTestsResponse{}, err
+	}
+
+	steps, err := parseGenerationScript(string(script), generators)
+	if err != nil {
+		response.Error = err.Error()
+		return response, nil
+	}
+	for _, step := range steps {
+		response.Tests = append(response.Tests, GeneratedTest{Test: step.test, Command: step.command})
+	}
+
+	workspace := filepath.Join("generate", strconv.Itoa(problemId))
+	if err = beginJudge(workspace); err != nil {
+		return GenerateTestsResponse{}, err
+	}
+	defer endJudge(workspace)
+	defer os.RemoveAll(workspace)
+
+	testsDir := filepath.Join(workspace, "tests")
+	if err = MakeDir(testsDir); err != nil {
+		return GenerateTestsResponse{}, err
+	}
+
+	if err = writeProblemResources(repository, manifest, workspace); err != nil {
+		return GenerateTestsResponse{}, err
+	}
+
+	for _, generator := range manifest.Generators {
+		used := make([]generationStep, 0)
+		for _, step := range steps {
+			if step.generator == generator.Name {
+				used = append(used, step)
+			}
+		}
+		if len(used) == 0 {
+			continue
+		}
+
+		runCmd, message, err := buildProblemProgram(ctx, repository, "generate", problemId, generator)
+		if err != nil {
+			return GenerateTestsResponse{}, err
+		}
+		if message != "" {
+			response.Error = fmt.Sprintf("generator %s: %s", generator.Name, message)
+			return response, nil
+		}
+
+		for _, step := range used {
+			inputFilePath := filepath.Join(testsDir, strconv.Itoa(step.test)+".in")
+			cmd := append(append([]string{}, runCmd...), step.args...)
+			if message, err = service.run(ctx, cmd, os.DevNull, inputFilePath); err != nil {
+				return GenerateTestsResponse{}, err
+			}
+			if message != "" {
+				response.Tests[step.test-1].Error = message
+				response.Error = fmt.Sprintf("test %d: generator %s failed", step.test, generator.Name)
+				return response, nil
+			}
+		}
+	}
+
+	validation := ValidationResponse{
+		ProblemId:   problemId,
+		Status:      ValidationNone.String(),
+		Tests:       make([]TestValidation, 0),
+		ValidatedAt: time.Now(),
+	}
+	response.Validation = &validation
+	if len(manifest.Validators) > 0 {
+		validator := manifest.Validators[0]
+		validation.Validator = &validator
+
+		runCmd, message, err := buildProblemProgram(ctx, repository, "generate", problemId, validator)
+		if err != nil {
+			return GenerateTestsResponse{}, err
+		}
+		if message != "" {
+			validation.Status = ValidationError.String()
+			validation.Error = message
+			response.Error = "validator: " + message
+			return response, nil
+		}
+
+		inputFilePaths := make([]string, 0, len(steps))
+		for _, step := range steps {
+			inputFilePaths = append(inputFilePaths, filepath.Join(testsDir, strconv.Itoa(step.test)+".in"))
+		}
+		if err = service.validationService.validateInputs(ctx, runCmd, workspace, inputFilePaths, &validation); err != nil {
+			return GenerateTestsResponse{}, err
+		}
+		if validation.Status != ValidationValid.String() {
+			response.Error = "generated tests were rejected by the validator"
+			return response, nil
+		}
+	}
+
+	runCmd, message, err := buildProblemProgram(ctx, repository, "generate", problemId, solution.PackageFile)
+	if err != nil {
+		return GenerateTestsResponse{}, err
+	}
+	if message != "" {
+		response.Error = "main solution: " + message
+		return response, nil
+	}
+
+	testCases := make([]PackageTestCase, 0, len(steps))
+	for _, step := range steps {
+		inputFilePath := filepath.Join(testsDir, strconv.Itoa(step.test)+".in")
+		outputFilePath := filepath.Join(testsDir, strconv.Itoa(step.test)+".out")
+		if message, err = service.run(ctx, runCmd, inputFilePath, outputFilePath); err != nil {
+			return GenerateTestsResponse{}, err
+		}
+		if message != "" {
+			response.Tests[step.test-1].Error = message
+			response.Error = fmt.Sprintf("test %d: main solution failed", step.test)
+			return response, nil
+		}
+
+		input, err := os.ReadFile(inputFilePath)
+		if err != nil {
+			return GenerateTestsResponse{}, err
+		}
+		output, err := os.ReadFile(outputFilePath)
+		if err != nil {
+			return GenerateTestsResponse{}, err
+		}
+
+		response.Tests[step.test-1].InputSize = int64(len(input))
+		response.Tests[step.test-1].OutputSize = int64(len(output))
+		testCases = append(testCases, PackageTestCase{Input: input, Output: output})
+	}
+
+	if err = repository.ReplaceTestcases(problemId, testCases); err != nil {
+		return GenerateTestsResponse{}, err
+	}
+
+	// 검증 결과를 남기지 못하면 남아 있던 결과의 버전이 새 버전과 달라서 다시 검증하기 전까지 채점하지 않는다
+	if validation.DataVersion, err = repository.GetTestcasesVersion(problemId); err != nil {
+		return GenerateTestsResponse{}, err
+	}
+	if err = service.validationService.saveValidation(validation); err != nil {
+		return GenerateTestsResponse{}, err
+	}
+
+	// 예제 번호는 가져온 패키지의 테스트를 가리키므로 테스트를 바꾸면 더 이상 맞지 않는다
+	manifest.TestCaseNum = len(testCases)
+	manifest.Samples = make([]int, 0)
+	if err = repository.SaveManifest(*manifest); err != nil {
+		return GenerateTestsResponse{}, err
+	}
+
+	response.TestCaseNum = len(testCases)
+	return response, nil
+}
+
+// run 은 프로그램을 한 번 실행한다. 프로그램이 실패한 이유는 message 로, 드레인 중이거나 파일을 열지 못하면 err 로 반환한다
+func (service *GenerationService) run(ctx context.Context, runCmd []string, inputFilePath, outputFilePath string) (string, error) {
+	result, _, _, stderr, err := executeProgramWithStderr(ctx, runCmd, inputFilePath, outputFilePath, int(service.timeLimit.Milliseconds()), 0)
+	if errors.Is(err, ErrDraining) {
+		return "", err
+	}
+	if result == JudgeUnknown {
+		return "", err
+	}
+	if result != JudgeCorrect {
+		message := result.String()
+		if stderr = strings.TrimSpace(stderr); stderr != "" {
+			message += ": " + stderr
+		}
+		return message, nil
+	}
+
+	return "", nil
+}
+
+// parseGenerationScript 는 생성 스크립트를 테스트 순서대로 읽는다. 테스트 번호는 1 부터 센다.
+// 한 줄이 "<generator> [args...]" 로 테스트 하나를 만들고, Polygon 스크립트처럼 끝에 "> $" 를 붙여도 된다.
+// 인자는 공백으로만 나누며 따옴표는 해석하지 않는다
+func parseGenerationScript(script string, generators map[string]bool) ([]generationStep, error) {
+	steps := make([]generationStep, 0)
+	for i, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if n := len(fields); n >= 2 && fields[n-2] == ">" {
+			if fields[n-1] != "$" {
+				return nil, fmt.Errorf("%w: line %d: only \"> $\" is supported", ErrInvalidGeneration, i+1)
+			}
+			fields = fields[:n-2]
+		}
+		if len(fields) == 0 || !generators[fields[0]] {
+			return nil, fmt.Errorf("%w: line %d: unknown generator", ErrInvalidGeneration, i+1)
+		}
+
+		steps = append(steps, generationStep{
+			test:      len(steps) + 1,
+			generator: fields[0],
+			args:      fields[1:],
+			command:   strings.Join(fields, " "),
+		})
+	}
+
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("%w: script has no tests", ErrInvalidGeneration)
+	}
+
+	return steps, nil
+}
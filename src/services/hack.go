@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.opentelemetry.io/otel/attribute"
+	. "leita/src/commands"
+	. "leita/src/entities"
+	"leita/src/repositories"
+	"leita/src/tracing"
+	. "leita/src/utils"
+)
+
+const (
+	// 해킹 입력의 최대 크기(MB)
+	defaultHackMaxInput = 1
+	// 응답에 담는 출력의 최대 길이
+	hackPreviewSize = 64 << 10
+)
+
+var (
+	ErrInvalidHack        = errors.New("invalid hack")
+	ErrHackTargetNotFound = errors.New("hack target submission not found")
+	// 검증기나 main 풀이가 없는 문제는 해킹 입력이 올바른지, 정답이 무엇인지 알 수 없다
+	ErrHackNotSupported = errors.New("problem does not support hacks")
+)
+
+// HackService 는 Codeforces 의 해킹처럼 다른 제출을 겨냥한 입력을 받아, 문제의 검증기로 입력을 검증한 뒤
+// main 풀이와 대상 제출을 실행해서 대상 제출이 틀리는지 확인한다. 누가 어떤 제출을 해킹할 수 있는지는 호출하는 쪽이 정한다
+type HackService struct {
+	problemService     *ProblemService
+	submitRepository   *repositories.SubmitRepository
+	validatorTimeLimit time.Duration
+	maxInput           int
+}
+
+func NewHackService() (*HackService, error) {
+	problemService, err := NewProblemService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	submitRepository, err := repositories.NewSubmitRepository()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	validatorTimeLimit, err := parseDurationEnv("JUDGE_VALIDATOR_TIME_LIMIT", defaultValidatorTimeLimit)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	maxInput, err := parseIntEnv("JUDGE_HACK_MAX_INPUT", defaultHackMaxInput)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &HackService{
+		problemService:     problemService,
+		submitRepository:   submitRepository,
+		validatorTimeLimit: validatorTimeLimit,
+		maxInput:           maxInput << 20,
+	}, nil
+}
+
+// Hack 은 해킹이 성공했고 AddToTests 가 켜져 있으면 해킹 입력과 main 풀이의 출력을 문제의 테스트 케이스로 붙인다.
+// 붙인 테스트 케이스는 이후 채점과 재채점에 쓰인다
+func (service *HackService) Hack(ctx context.Context, dto HackDTO) (HackResponse, error) {
+	if len(dto.Input) == 0 || len(dto.Input) > service.maxInput {
+		return HackResponse{}, fmt.Errorf("%w: input must be 1 to %d bytes", ErrInvalidHack, service.maxInput)
+	}
+
+	submits, err := service.submitRepository.GetSubmits(GetSubmitsDTO{SubmitId: dto.SubmitId})
+	if err != nil {
+		log.Error(err)
+		return HackResponse{}, err
+	}
+	if len(submits) == 0 {
+		return HackResponse{}, ErrHackTargetNotFound
+	}
+	target := submits[0]
+
+	if !IsSupported(target.Language) {
+		return HackResponse{}, fmt.Errorf("%w: language %q is not supported on this node", ErrInvalidHack, target.Language)
+	}
+
+	id := RandomInt(int(math.Pow10(11)), int(math.Pow10(12)-1))
+	workspace := filepath.Join("hack", strconv.Itoa(id))
+	if err = beginJudge(workspace); err != nil {
+		return HackResponse{}, err
+	}
+	defer endJudge(workspace)
+	defer os.RemoveAll(workspace)
+
+	ctx, span := tracing.Start(ctx, "Hack", submissionAttributes(target.SubmitId, target.ProblemId, target.Language)...)
+	response, err := service.hack(ctx, workspace, id, target, dto)
+	span.SetAttributes(attribute.String("judge.hack", response.Status), attribute.String("judge.result", response.Result))
+	tracing.End(span, err)
+	if err != nil {
+		log.WithContext(ctx).Error(err)
+		return HackResponse{}, err
+	}
+
+	log.WithContext(ctx).Infow("해킹 완료", "submitId", target.SubmitId, "problemId", target.ProblemId, "status", response.Status, "result", response.Result, "testCaseNum", response.TestCaseNum)
+	return response, nil
+}
+
+// 검증기, main 풀이, 대상 제출을 같은 작업 디렉터리에서 차례로 빌드하고 실행한다
+func (service *HackService) hack(ctx context.Context, workspace string, id int, target GetSubmitDAO, dto HackDTO) (HackResponse, error) {
+	repository := service.problemService.repository
+
+	response := HackResponse{
+		SubmitId:  target.SubmitId,
+		ProblemId: target.ProblemId,
+	}
+	fail := func(message string) (HackResponse, error) {
+		response.Status = HackFailed.String()
+		response.Error = message
+		return response, nil
+	}
+
+	problemInfo, err := repository.GetProblemInfo(target.ProblemId)
+	if err != nil {
+		return HackResponse{}, err
+	}
+	if err = checkTestData(problemInfo); err != nil {
+		return HackResponse{}, err
+	}
+
+	manifest, err := repository.GetManifest(target.ProblemId)
+	if err != nil {
+		return HackResponse{}, err
+	}
+	if manifest == nil || len(manifest.Validators) == 0 {
+		return HackResponse{}, fmt.Errorf("%w: no validator", ErrHackNotSupported)
+	}
+	var solution *PackageSolution
+	for i := range manifest.Solutions {
+		if manifest.Solutions[i].Tag == MainSolutionTag {
+			solution = &manifest.Solutions[i]
+			break
+		}
+	}
+	if solution == nil {
+		return HackResponse{}, fmt.Errorf("%w: no main solution", ErrHackNotSupported)
+	}
+
+	if err = MakeDir(workspace); err != nil {
+		return HackResponse{}, err
+	}
+	if err = writeProblemResources(repository, manifest, workspace); err != nil {
+		return HackResponse{}, err
+	}
+
+	inputFilePath := filepath.Join(workspace, "input")
+	expectedFilePath := filepath.Join(workspace, "expected")
+	outputFilePath := filepath.Join(workspace, "output")
+	if err = os.WriteFile(inputFilePath, dto.Input, 0644); err != nil {
+		return HackResponse{}, err
+	}
+
+	runCmd, message, err := buildProblemProgram(ctx, repository, "hack", id, manifest.Validators[0])
+	if err != nil {
+		return HackResponse{}, err
+	}
+	if message != "" {
+		return fail("validator: " + message)
+	}
+
+	result, _, _, stderr, err := executeProgramWithStderr(ctx, runCmd, inputFilePath, filepath.Join(workspace, "validator"), int(service.validatorTimeLimit.Milliseconds()), 0)
+	if result == JudgeUnknown {
+		return HackResponse{}, err
+	}
+	if result != JudgeCorrect {
+		response.Status = HackInvalidInput.String()
+		response.ValidatorError = strings.TrimSpace(stderr)
+		return response, nil
+	}
+
+	// main 풀이도 제출과 같은 제한 안에 끝나야 한다
+	runCmd, message, err = buildProblemProgram(ctx, repository, "hack", id, solution.PackageFile)
+	if err != nil {
+		return HackResponse{}, err
+	}
+	if message != "" {
+		return fail("main solution: " + message)
+	}
+
+	timeLimit := ApplyLimitMultiplier(problemInfo.TimeLimit, TimeLimitMultiplier(solution.Language))
+	memoryLimit := ApplyLimitMultiplier(problemInfo.MemoryLimit, MemoryLimitMultiplier(solution.Language))
+	result, _, _, stderr, err = executeProgramWithStderr(ctx, runCmd, inputFilePath, expectedFilePath, timeLimit, memoryLimit)
+	if result == JudgeUnknown {
+		return HackResponse{}, err
+	}
+	if result != JudgeCorrect {
+		return fail(fmt.Sprintf("main solution: %s: %s", result, strings.TrimSpace(stderr)))
+	}
+
+	code, err := service.submitRepository.GetCode(target.SubmitId, target.Language)
+	if err != nil {
+		return HackResponse{}, err
+	}
+
+	command := Commands[target.Language]
+	buildCmd := ReplaceCommand(command.BuildCmd, "hack", id)
+	runCmd = ReplaceCommand(command.RunCmd, "hack", id)
+	if result, err := buildSource(ctx, id, target.Language, "hack", code, buildCmd); result != JudgeCorrect {
+		if errors.Is(err, ErrDraining) {
+			return HackResponse{}, err
+		}
+		return fail(fmt.Sprintf("target: %s: %s", result, ErrStrIfNotNil(err)))
+	}
+
+	// 채점할 때처럼 JVM 같은 런타임을 미리 데운 뒤에 잰다
+	timeLimit = ApplyLimitMultiplier(problemInfo.TimeLimit, TimeLimitMultiplier(target.Language))
+	memoryLimit = ApplyLimitMultiplier(problemInfo.MemoryLimit, MemoryLimitMultiplier(target.Language))
+	for i := 0; i < WarmUpRuns(target.Language); i++ {
+		_, _, _, _ = executeProgram(ctx, runCmd, inputFilePath, filepath.Join(workspace, "warmup"), timeLimit, memoryLimit)
+	}
+
+	result, usedTime, usedMemory, err := executeProgram(ctx, runCmd, inputFilePath, outputFilePath, timeLimit, memoryLimit)
+	if result == JudgeUnknown {
+		return HackResponse{}, err
+	}
+	if result == JudgeCorrect {
+		equal, err := checkDifference(ctx, ParseChecker(problemInfo.Checker), outputFilePath, expectedFilePath)
+		if err != nil {
+			return HackResponse{}, err
+		}
+		if !equal {
+			result = JudgeWrong
+		}
+	}
+
+	expected, err := ReadFilePreview(expectedFilePath, hackPreviewSize)
+	if err != nil {
+		return HackResponse{}, err
+	}
+	output, err := ReadFilePreview(outputFilePath, hackPreviewSize)
+	if err != nil {
+		return HackResponse{}, err
+	}
+
+	response.Result = result.String()
+	response.UsedTime = usedTime
+	response.UsedMemory = usedMemory
+	response.Expected = string(expected)
+	response.Output = string(output)
+
+	if result == JudgeCorrect {
+		response.Status = HackUnsuccessful.String()
+		return response, nil
+	}
+	response.Status = HackSuccessful.String()
+
+	if dto.AddToTests {
+		expected, err := os.ReadFile(expectedFilePath)
+		if err != nil {
+			return HackResponse{}, err
+		}
+
+		added, testCaseNum, err := repository.AddTestcase(target.ProblemId, PackageTestCase{Input: dto.Input, Output: expected})
+		if err != nil {
+			return HackResponse{}, err
+		}
+		if added {
+			response.TestCaseNum = testCaseNum
+			manifest.TestCaseNum = testCaseNum
+			if err = repository.SaveManifest(*manifest); err != nil {
+				return HackResponse{}, err
+			}
+		}
+	}
+
+	return response, nil
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/commands"
+	"leita/src/dataSources"
+	. "leita/src/entities"
+	. "leita/src/utils"
+)
+
+const (
+	defaultHealthCheckTimeout = 2 * time.Second
+	// kotlinc 처럼 느린 컴파일러도 통과할 수 있도록 넉넉하게 잡는다
+	defaultLanguageProbeTimeout  = time.Minute
+	defaultLanguageProbeInterval = 5 * time.Minute
+	defaultMinFreeDiskMB         = 512
+)
+
+// HealthService 는 채점 서버가 요청을 받을 준비가 되었는지 확인한다.
+// 데이터베이스, 오브젝트 스토리지, 디스크는 요청마다 확인하고,
+// JUDGE_LANGUAGES 에 있는 언어의 컴파일러와 런타임은 백그라운드에서 주기적으로 Hello, World! 를 빌드하고 실행해 본 결과를 쓴다
+type HealthService struct {
+	dataSource    *dataSources.DataSource
+	minFreeDisk   uint64
+	probeTimeout  time.Duration
+	probeInterval time.Duration
+
+	mutex          sync.RWMutex
+	languageChecks map[string]HealthCheck
+}
+
+func NewHealthService() (*HealthService, error) {
+	dataSource, err := dataSources.GetDataSource()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	minFreeDiskMB := int64(defaultMinFreeDiskMB)
+	if value := GetEnv("JUDGE_MIN_FREE_DISK"); value != "" {
+		minFreeDiskMB, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || minFreeDiskMB < 0 {
+			err = fmt.Errorf("invalid JUDGE_MIN_FREE_DISK: %s", value)
+			log.Error(err)
+			return nil, err
+		}
+	}
+
+	probeTimeout, err := parseDurationEnv("JUDGE_LANGUAGE_PROBE_TIMEOUT", defaultLanguageProbeTimeout)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	probeInterval, err := parseDurationEnv("JUDGE_LANGUAGE_PROBE_INTERVAL", defaultLanguageProbeInterval)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	// 첫 확인이 끝나기 전에는 준비되지 않은 것으로 본다
+	languageChecks := make(map[string]HealthCheck)
+	for _, language := range Languages() {
+		languageChecks[language] = HealthCheck{
+			Name:   languageCheckName(language),
+			Status: HealthUnknown.String(),
+			Error:  "not checked yet",
+		}
+	}
+
+	service := &HealthService{
+		dataSource:     dataSource,
+		minFreeDisk:    uint64(minFreeDiskMB) << 20,
+		probeTimeout:   probeTimeout,
+		probeInterval:  probeInterval,
+		languageChecks: languageChecks,
+	}
+	go service.probeLanguages()
+
+	return service, nil
+}
+
+func parseDurationEnv(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := GetEnv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid %s: %s", key, value)
+	}
+
+	return duration, nil
+}
+
+// Check 는 모든 확인 결과를 모아 반환한다. 하나라도 UP 이 아니면 전체 상태는 DOWN 이다
+func (service *HealthService) Check(ctx context.Context) HealthResponse {
+	checks := []HealthCheck{
+		runHealthCheck("database", func() error {
+			ctx, cancel := context.WithTimeout(ctx, defaultHealthCheckTimeout)
+			defer cancel()
+			return service.dataSource.GetDatabase().PingContext(ctx)
+		}),
+		runHealthCheck("objectStorage", func() error {
+			ctx, cancel := context.WithTimeout(ctx, defaultHealthCheckTimeout)
+			defer cancel()
+			return service.dataSource.GetObjectStorage().Ping(ctx)
+		}),
+		runHealthCheck("disk", service.checkDisk),
+		// 종료 중이면 더 이상 요청을 보내지 않도록 준비되지 않은 것으로 알린다
+		runHealthCheck("draining", func() error {
+			if IsDraining() {
+				return ErrDraining
+			}
+			return nil
+		}),
+	}
+
+	service.mutex.RLock()
+	languages := make([]string, 0, len(service.languageChecks))
+	for language := range service.languageChecks {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	for _, language := range languages {
+		checks = append(checks, service.languageChecks[language])
+	}
+	service.mutex.RUnlock()
+
+	status := HealthUp
+	for _, check := range checks {
+		if check.Status != HealthUp.String() {
+			status = HealthDown
+			break
+		}
+	}
+
+	return HealthResponse{
+		Status: status.String(),
+		Checks: checks,
+	}
+}
+
+func (service *HealthService) Ready(ctx context.Context) bool {
+	return service.Check(ctx).Status == HealthUp.String()
+}
+
+// 채점 작업 디렉터리(현재 디렉터리)의 남은 용량을 확인한다
+func (service *HealthService) checkDisk() error {
+	free, err := FreeDiskSpace(".")
+	if err != nil {
+		return err
+	}
+
+	if free < service.minFreeDisk {
+		return fmt.Errorf("free disk space %dMB is below %dMB", free>>20, service.minFreeDisk>>20)
+	}
+
+	return nil
+}
+
+func (service *HealthService) probeLanguages() {
+	for {
+		for _, language := range Languages() {
+			check := runHealthCheck(languageCheckName(language), func() error {
+				return service.probeLanguage(language, Commands[language])
+			})
+
+			service.mutex.Lock()
+			service.languageChecks[language] = check
+			service.mutex.Unlock()
+		}
+
+		time.Sleep(service.probeInterval)
+	}
+}
+
+// 임시 디렉터리를 작업 디렉터리로 삼아 빌드하고 실행하므로, 진행 중인 채점의 파일과 섞이지 않는다.
+// 동시에 도는 채점의 시간이 흔들리지 않도록 실행할 때만 실행 슬롯을 하나 잡는다. 빌드는 채점처럼 슬롯 밖에서 한다
+func (service *HealthService) probeLanguage(language string, command Command) error {
+	dir, err := os.MkdirTemp("", "leita-health-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	sourceDir := filepath.Join(dir, "health", "0")
+	if err = MakeDir(sourceDir); err != nil {
+		return err
+	}
+
+	sourceFilePath := filepath.Join(sourceDir, "Main."+FileExtension(language))
+	if err = os.WriteFile(sourceFilePath, []byte(command.HelloWorld), 0644); err != nil {
+		return err
+	}
+
+	if len(command.BuildCmd) > 0 {
+		ctx, cancel := context.WithTimeout(judgeContext, service.probeTimeout)
+		_, err = runProbeCommand(ctx, dir, ReplaceCommand(command.BuildCmd, "health", 0))
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+
+	// 슬롯을 기다린 시간이 실행 시간 제한에 들어가지 않도록 슬롯을 잡은 뒤에 제한을 건다
+	cpu := acquireExecutionSlot()
+	defer releaseExecutionSlot(cpu)
+
+	ctx, cancel := context.WithTimeout(judgeContext, service.probeTimeout)
+	defer cancel()
+
+	output, err := runProbeCommand(ctx, dir, ReplaceCommand(command.RunCmd, "health", 0))
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(output) != HelloWorldOutput {
+		return fmt.Errorf("unexpected output: %q", output)
+	}
+
+	return nil
+}
+
+func runProbeCommand(ctx context.Context, dir string, args []string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %w\n%s", args[0], err, stderr.String())
+	}
+
+	return stdout.String(), nil
+}
+
+func runHealthCheck(name string, check func() error) HealthCheck {
+	startTime := time.Now()
+	err := check()
+
+	result := HealthCheck{
+		Name:      name,
+		Status:    HealthUp.String(),
+		Latency:   time.Since(startTime).Milliseconds(),
+		CheckedAt: startTime,
+	}
+	if err != nil {
+		log.Errorw("준비 상태 확인 실패", "check", name, "error", err)
+		result.Status = HealthDown.String()
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+func languageCheckName(language string) string {
+	return "language:" + language
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/commands"
+	. "leita/src/entities"
+	. "leita/src/utils"
+)
+
+const versionTimeout = 30 * time.Second
+
+type toolchainVersion struct {
+	version string
+	err     error
+}
+
+// LanguageService 는 이 인스턴스가 채점하는 언어와 설치된 컴파일러, 런타임의 버전을 알려 준다.
+// 실행 중에 툴체인이 바뀌지는 않으므로 버전은 처음 한 번만 확인한다
+type LanguageService struct {
+	versionsOnce sync.Once
+	versions     map[string]toolchainVersion
+}
+
+func NewLanguageService() (*LanguageService, error) {
+	return &LanguageService{}, nil
+}
+
+func (service *LanguageService) GetLanguages() []LanguageResponse {
+	service.versionsOnce.Do(service.detectVersions)
+
+	languages := Languages()
+	responses := make([]LanguageResponse, 0, len(languages))
+	for _, language := range languages {
+		command := Commands[language]
+		version := service.versions[language]
+
+		responses = append(responses, LanguageResponse{
+			Language:              language,
+			Extension:             FileExtension(language),
+			Available:             version.err == nil,
+			Version:               version.version,
+			Error:                 ErrStrIfNotNil(version.err),
+			BuildCmd:              command.BuildCmd,
+			RunCmd:                command.RunCmd,
+			TimeLimitMultiplier:   TimeLimitMultiplier(language),
+			MemoryLimitMultiplier: MemoryLimitMultiplier(language),
+			WarmUpRuns:            WarmUpRuns(language),
+		})
+	}
+
+	return responses
+}
+
+func (service *LanguageService) detectVersions() {
+	languages := Languages()
+	versions := make(map[string]toolchainVersion, len(languages))
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, language := range languages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			version, err := detectVersion(Commands[language].VersionCmd)
+			if err != nil {
+				log.Error(language, " 버전 확인 실패: ", err)
+			}
+
+			mutex.Lock()
+			versions[language] = toolchainVersion{version: version, err: err}
+			mutex.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	service.versions = versions
+}
+
+// java -version 처럼 표준 에러로 버전을 출력하는 명령도 있어서, 두 출력을 합친 첫 줄을 버전으로 쓴다
+func detectVersion(versionCmd []string) (string, error) {
+	if len(versionCmd) == 0 {
+		return "", fmt.Errorf("no version command")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, versionCmd[0], versionCmd[1:]...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %w", versionCmd[0], err)
+	}
+
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			return line, nil
+		}
+	}
+
+	return "", fmt.Errorf("%s: empty version output", versionCmd[0])
+}
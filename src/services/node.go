@@ -0,0 +1,367 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/entities"
+	. "leita/src/utils"
+)
+
+const (
+	defaultNodePollInterval = 10 * time.Second
+	nodeCheckTimeout        = 5 * time.Second
+)
+
+var (
+	ErrUnsupportedLanguage = errors.New("no judge node supports the language")
+	ErrNoHealthyNode       = errors.New("no healthy judge node for the language")
+)
+
+type judgeNode struct {
+	url      string
+	inFlight atomic.Int64
+
+	mutex     sync.RWMutex
+	healthy   bool
+	languages map[string]LanguageResponse
+	checkedAt time.Time
+	err       error
+}
+
+func (node *judgeNode) response() NodeResponse {
+	node.mutex.RLock()
+	defer node.mutex.RUnlock()
+
+	languages := make([]string, 0, len(node.languages))
+	for language := range node.languages {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	return NodeResponse{
+		Url:       node.url,
+		Healthy:   node.healthy,
+		Languages: languages,
+		InFlight:  node.inFlight.Load(),
+		CheckedAt: node.checkedAt,
+		Error:     ErrStrIfNotNil(node.err),
+	}
+}
+
+func (node *judgeNode) supports(language string) bool {
+	node.mutex.RLock()
+	defer node.mutex.RUnlock()
+
+	_, exists := node.languages[language]
+	return exists
+}
+
+func (node *judgeNode) isHealthy() bool {
+	node.mutex.RLock()
+	defer node.mutex.RUnlock()
+
+	return node.healthy
+}
+
+// NodeRegistry 는 라우터 모드에서 채점 노드와 노드가 지원하는 언어를 관리한다.
+// 노드는 JUDGE_NODES=http://judge-c:1323,http://judge-java:1323 로 정하거나 실행 중에 등록하고,
+// 주기적으로 각 노드의 /readyz 와 /api/languages 를 확인해서 상태와 언어를 갱신한다
+type NodeRegistry struct {
+	apiKey       string
+	pollInterval time.Duration
+	client       *http.Client
+
+	mutex sync.RWMutex
+	nodes map[string]*judgeNode
+}
+
+func NewNodeRegistry() (*NodeRegistry, error) {
+	pollInterval := defaultNodePollInterval
+	if value := GetEnv("JUDGE_NODE_POLL_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			err = fmt.Errorf("invalid JUDGE_NODE_POLL_INTERVAL: %s", value)
+			log.Error(err)
+			return nil, err
+		}
+		pollInterval = parsed
+	}
+
+	registry := &NodeRegistry{
+		apiKey:       GetEnv("JUDGE_NODE_API_KEY"),
+		pollInterval: pollInterval,
+		client:       &http.Client{Timeout: nodeCheckTimeout},
+		nodes:        make(map[string]*judgeNode),
+	}
+
+	if value := GetEnv("JUDGE_NODES"); value != "" {
+		for _, nodeUrl := range strings.Split(value, ",") {
+			if _, err := registry.Register(strings.TrimSpace(nodeUrl)); err != nil {
+				log.Error(err)
+				return nil, err
+			}
+		}
+	}
+
+	go registry.poll()
+
+	return registry, nil
+}
+
+// ApiKey 는 노드에 요청할 때 쓰는 키다
+func (registry *NodeRegistry) ApiKey() string {
+	return registry.apiKey
+}
+
+// Register 는 노드를 추가하고 바로 상태를 확인한다. 이미 있는 노드면 상태만 다시 확인한다
+func (registry *NodeRegistry) Register(nodeUrl string) (NodeResponse, error) {
+	parsed, err := url.Parse(nodeUrl)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		err = fmt.Errorf("invalid node url: %s", nodeUrl)
+		log.Error(err)
+		return NodeResponse{}, err
+	}
+	nodeUrl = strings.TrimRight(nodeUrl, "/")
+
+	registry.mutex.Lock()
+	node, exists := registry.nodes[nodeUrl]
+	if !exists {
+		node = &judgeNode{url: nodeUrl}
+		registry.nodes[nodeUrl] = node
+		log.Info("채점 노드 등록: ", nodeUrl)
+	}
+	registry.mutex.Unlock()
+
+	registry.check(node)
+	return node.response(), nil
+}
+
+func (registry *NodeRegistry) Unregister(nodeUrl string) bool {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	nodeUrl = strings.TrimRight(nodeUrl, "/")
+	if _, exists := registry.nodes[nodeUrl]; !exists {
+		return false
+	}
+
+	delete(registry.nodes, nodeUrl)
+	log.Info("채점 노드 삭제: ", nodeUrl)
+	return true
+}
+
+func (registry *NodeRegistry) Nodes() []NodeResponse {
+	nodes := registry.list()
+
+	responses := make([]NodeResponse, 0, len(nodes))
+	for _, node := range nodes {
+		responses = append(responses, node.response())
+	}
+
+	return responses
+}
+
+// Languages 는 정상인 노드가 하나라도 지원하는 언어를 반환한다. 같은 언어는 URL 순으로 앞선 노드의 정보를 쓴다
+func (registry *NodeRegistry) Languages() []LanguageResponse {
+	languages := make(map[string]LanguageResponse)
+	for _, node := range registry.list() {
+		if !node.isHealthy() {
+			continue
+		}
+
+		node.mutex.RLock()
+		for language, languageResponse := range node.languages {
+			if _, exists := languages[language]; !exists {
+				languages[language] = languageResponse
+			}
+		}
+		node.mutex.RUnlock()
+	}
+
+	result := make([]LanguageResponse, 0, len(languages))
+	for _, languageResponse := range languages {
+		result = append(result, languageResponse)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Language < result[j].Language
+	})
+
+	return result
+}
+
+func (registry *NodeRegistry) HasHealthyNode() bool {
+	for _, node := range registry.list() {
+		if node.isHealthy() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Pick 은 language 를 지원하는 정상 노드 중 처리 중인 요청이 가장 적은 노드를 고른다.
+// exclude 에 있는 노드는 이미 실패한 노드라서 건너뛴다. 다 쓴 뒤에는 release 를 호출해야 한다
+func (registry *NodeRegistry) Pick(language string, exclude map[string]bool) (string, func(), error) {
+	var picked *judgeNode
+	supported := false
+	for _, node := range registry.list() {
+		if !node.supports(language) {
+			continue
+		}
+		supported = true
+
+		if exclude[node.url] || !node.isHealthy() {
+			continue
+		}
+		if picked == nil || node.inFlight.Load() < picked.inFlight.Load() {
+			picked = node
+		}
+	}
+
+	if picked == nil {
+		if !supported {
+			return "", nil, ErrUnsupportedLanguage
+		}
+		return "", nil, ErrNoHealthyNode
+	}
+
+	picked.inFlight.Add(1)
+	return picked.url, func() { picked.inFlight.Add(-1) }, nil
+}
+
+// MarkUnhealthy 는 요청을 보내다 실패한 노드를 다음 확인 때까지 쓰지 않는다
+func (registry *NodeRegistry) MarkUnhealthy(nodeUrl string, err error) {
+	registry.mutex.RLock()
+	node, exists := registry.nodes[nodeUrl]
+	registry.mutex.RUnlock()
+	if !exists {
+		return
+	}
+
+	node.mutex.Lock()
+	node.healthy = false
+	node.err = err
+	node.mutex.Unlock()
+
+	log.Warn("채점 노드 사용 중지: ", nodeUrl, ", ", err)
+}
+
+func (registry *NodeRegistry) list() []*judgeNode {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	nodes := make([]*judgeNode, 0, len(registry.nodes))
+	for _, node := range registry.nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].url < nodes[j].url
+	})
+
+	return nodes
+}
+
+func (registry *NodeRegistry) poll() {
+	for {
+		time.Sleep(registry.pollInterval)
+
+		var wg sync.WaitGroup
+		for _, node := range registry.list() {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				registry.check(node)
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func (registry *NodeRegistry) check(node *judgeNode) {
+	languages, err := registry.fetchLanguages(node.url)
+	if err == nil {
+		err = registry.checkReady(node.url)
+	}
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	wasHealthy := node.healthy
+	node.healthy = err == nil
+	node.checkedAt = time.Now()
+	node.err = err
+	if languages != nil {
+		node.languages = languages
+	}
+
+	if wasHealthy && err != nil {
+		log.Warn("채점 노드 비정상: ", node.url, ", ", err)
+	} else if !wasHealthy && err == nil {
+		log.Info("채점 노드 정상: ", node.url)
+	}
+}
+
+func (registry *NodeRegistry) checkReady(nodeUrl string) error {
+	response, err := registry.get(nodeUrl + "/readyz")
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("readyz returned %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+// 노드에 설치된 툴체인으로 실제 사용할 수 있는 언어만 고른다
+func (registry *NodeRegistry) fetchLanguages(nodeUrl string) (map[string]LanguageResponse, error) {
+	response, err := registry.get(nodeUrl + "/api/languages")
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("languages returned %d", response.StatusCode)
+	}
+
+	var languageResponses []LanguageResponse
+	if err = json.NewDecoder(response.Body).Decode(&languageResponses); err != nil {
+		return nil, err
+	}
+
+	languages := make(map[string]LanguageResponse, len(languageResponses))
+	for _, languageResponse := range languageResponses {
+		if languageResponse.Available {
+			languages[languageResponse.Language] = languageResponse
+		}
+	}
+
+	return languages, nil
+}
+
+func (registry *NodeRegistry) get(requestUrl string) (*http.Response, error) {
+	request, err := http.NewRequest(http.MethodGet, requestUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if registry.apiKey != "" {
+		request.Header.Set("X-Api-Key", registry.apiKey)
+	}
+
+	return registry.client.Do(request)
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/entities"
+	"leita/src/polygon"
+	"leita/src/repositories"
+)
+
+// 채점 서버는 체커를 실행하지 않으므로, 같은 방법으로 출력을 비교할 수 있는 표준 체커만 받는다
+var packageCheckers = map[string]CheckerEnum{
+	"std::wcmp.cpp": CheckerTokens,
+	"std::lcmp.cpp": CheckerLines,
+}
+
+// 다른 체커로 채점하면 패키지와 결과가 달라지므로 가져오지 않는다
+var ErrUnsupportedChecker = errors.New("unsupported checker")
+
+type PackageService struct {
+	repository        *repositories.ProblemRepository
+	validationService *ValidationService
+}
+
+func NewPackageService() (*PackageService, error) {
+	repository, err := repositories.NewProblemRepository()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	validationService, err := NewValidationService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &PackageService{
+		repository:        repository,
+		validationService: validationService,
+	}, nil
+}
+
+// ProblemObjectPath 는 문제 패키지에서 가져온 파일의 오브젝트 스토리지 이름이다. name 은 polygon.CleanPath 로 정리한 상대 경로여야 한다
+func ProblemObjectPath(problemId int, name string) string {
+	return filepath.Join("problems", strconv.Itoa(problemId), name)
+}
+
+// Import 는 Polygon 패키지로 문제의 제한과 테스트 케이스를 바꾸고,
+// 체커, 검증기, 풀이, 지문은 오브젝트 스토리지의 problems/{problemId}/ 아래에 manifest.json 과 함께 올린다.
+// 가져온 뒤에는 패키지의 검증기로 테스트 입력을 검증한다
+func (service *PackageService) Import(ctx context.Context, problemId int, archive []byte) (ImportPackageResponse, error) {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		log.Error(err)
+		return ImportPackageResponse{}, err
+	}
+
+	pkg, err := polygon.Parse(reader)
+	if err != nil {
+		log.Error(err)
+		return ImportPackageResponse{}, err
+	}
+
+	checker := CheckerExact
+	if pkg.Checker != nil {
+		supported, exists := packageCheckers[pkg.Checker.Name]
+		if !exists {
+			err = fmt.Errorf("%w: %q, only std::wcmp.cpp and std::lcmp.cpp are supported", ErrUnsupportedChecker, pkg.Checker.Name)
+			log.Error(err)
+			return ImportPackageResponse{}, err
+		}
+		checker = supported
+	}
+
+	// 생성기와 생성 스크립트는 API 로만 등록하므로 패키지를 다시 가져와도 남겨둔다
+	previous, err := service.repository.GetManifest(problemId)
+	if err != nil {
+		log.Error(err)
+		return ImportPackageResponse{}, err
+	}
+
+	manifest := ProblemManifest{
+		ProblemId:   problemId,
+		ShortName:   pkg.ShortName,
+		Names:       pkg.Names,
+		TimeLimit:   pkg.TimeLimit,
+		MemoryLimit: pkg.MemoryLimit,
+		TestCaseNum: len(pkg.Tests),
+		Samples:     make([]int, 0),
+		Validators:  make([]PackageFile, 0),
+		Solutions:   make([]PackageSolution, 0),
+		Statements:  make([]PackageStatement, 0),
+		Resources:   make([]PackageFile, 0),
+		Generators:  make([]PackageFile, 0),
+		ImportedAt:  time.Now(),
+	}
+	if previous != nil && previous.Generators != nil {
+		manifest.Generators = previous.Generators
+		manifest.Script = previous.Script
+	}
+	warnings := make([]string, 0)
+	objects := make(map[string][]byte)
+
+	addSource := func(source polygon.Source) PackageFile {
+		objectPath := ProblemObjectPath(problemId, source.Path)
+		objects[objectPath] = source.Content
+		if source.Language == "" {
+			warnings = append(warnings, fmt.Sprintf("%s: unsupported source type %s", source.Path, source.Type))
+		}
+
+		return PackageFile{
+			Path:     objectPath,
+			Type:     source.Type,
+			Language: source.Language,
+			Name:     source.Name,
+		}
+	}
+
+	testCases := make([]PackageTestCase, 0, len(pkg.Tests))
+	for i, test := range pkg.Tests {
+		testCases = append(testCases, PackageTestCase{Input: test.Input, Output: test.Answer})
+		if test.Sample {
+			manifest.Samples = append(manifest.Samples, i)
+		}
+	}
+
+	if pkg.Checker != nil {
+		checkerFile := addSource(*pkg.Checker)
+		manifest.Checker = &checkerFile
+	}
+
+	for _, validator := range pkg.Validators {
+		manifest.Validators = append(manifest.Validators, addSource(validator))
+	}
+
+	for _, solution := range pkg.Solutions {
+		manifest.Solutions = append(manifest.Solutions, PackageSolution{
+			PackageFile: addSource(solution.Source),
+			Tag:         solution.Tag,
+		})
+	}
+
+	for _, resource := range pkg.Resources {
+		objectPath := ProblemObjectPath(problemId, resource.Path)
+		objects[objectPath] = resource.Content
+		manifest.Resources = append(manifest.Resources, PackageFile{Path: objectPath})
+	}
+
+	for _, statement := range pkg.Statements {
+		objectPath := ProblemObjectPath(problemId, statement.Path)
+		objects[objectPath] = statement.Content
+		manifest.Statements = append(manifest.Statements, PackageStatement{
+			Path:     objectPath,
+			Type:     statement.Type,
+			Language: statement.Language,
+		})
+	}
+
+	content, err := json.Marshal(manifest)
+	if err != nil {
+		log.Error(err)
+		return ImportPackageResponse{}, err
+	}
+	objects[ProblemObjectPath(problemId, "manifest.json")] = content
+
+	importProblemDTO := ImportProblemDTO{
+		ProblemId:   problemId,
+		TimeLimit:   pkg.TimeLimit,
+		MemoryLimit: pkg.MemoryLimit,
+		Checker:     checker.String(),
+		TestCases:   testCases,
+		Objects:     objects,
+	}
+	if err = service.repository.ImportProblem(importProblemDTO); err != nil {
+		log.Error(err)
+		return ImportPackageResponse{}, err
+	}
+
+	// 패키지는 이미 가져왔으므로 검증하지 못했더라도 가져오기는 성공으로 보고 경고만 남긴다
+	validation, err := service.validationService.Validate(ctx, problemId)
+	if err != nil {
+		log.Error(err)
+		warnings = append(warnings, "validation failed: "+err.Error())
+	}
+
+	log.Infow("문제 패키지 가져오기 완료", "problemId", problemId, "shortName", pkg.ShortName, "testCaseNum", len(testCases), "warnings", len(warnings))
+
+	return ImportPackageResponse{
+		ProblemId:   problemId,
+		ShortName:   pkg.ShortName,
+		Names:       pkg.Names,
+		TimeLimit:   pkg.TimeLimit,
+		MemoryLimit: pkg.MemoryLimit,
+		TestCaseNum: len(testCases),
+		Checker:     manifest.Checker,
+		Validators:  manifest.Validators,
+		Solutions:   manifest.Solutions,
+		Validation:  &validation,
+		Warnings:    warnings,
+	}, nil
+}
@@ -5,20 +5,49 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime/debug"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gofiber/fiber/v2/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"leita/src/caches"
+	. "leita/src/commands"
 	. "leita/src/entities"
+	"leita/src/loggers"
+	"leita/src/metrics"
 	"leita/src/repositories"
+	"leita/src/tracing"
 	. "leita/src/utils"
 )
 
+const (
+	// 로그에 남길 예상/실제 결과의 최대 길이
+	previewSize = 1024
+	// 실행 결과로 돌려줄 출력의 최대 길이
+	runOutputPreviewSize = 64 * 1024
+	waitDelay            = time.Second
+)
+
+var (
+	errSkippedTestCase = errors.New("skipped testcase")
+	// 실행이 끝난 뒤에 잰 최대 메모리가 메모리 제한을 넘었다
+	ErrMemoryLimitExceeded = errors.New("memory limit exceeded")
+	// 검증을 통과하지 못한 테스트 데이터로는 채점하지 않는다
+	ErrInvalidTestData = errors.New("problem test data has not passed validation")
+)
+
 type ProblemService struct {
-	repository *repositories.ProblemRepository
+	repository    *repositories.ProblemRepository
+	testCaseCache *caches.TestCaseCache
+	submissions   *submissionTracker
 }
 
 func NewProblemService() (*ProblemService, error) {
@@ -28,12 +57,148 @@ func NewProblemService() (*ProblemService, error) {
 		return nil, err
 	}
 
+	testCaseCache, err := caches.GetTestCaseCache()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	submissions, err := getSubmissionTracker()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
 	return &ProblemService{
-		repository: repository,
+		repository:    repository,
+		testCaseCache: testCaseCache,
+		submissions:   submissions,
 	}, nil
 }
 
-func (service *ProblemService) SubmitProblem(dto SubmitProblemDTO) (JudgeResultEnum, int64, int64, error) {
+func NewSubmitProblemDTO(problemId, submitId int, language string, code []byte) SubmitProblemDTO {
+	command := Commands[language]
+
+	return SubmitProblemDTO{
+		ProblemId:  problemId,
+		SubmitId:   submitId,
+		Language:   language,
+		Code:       code,
+		BuildCmd:   ReplaceCommand(command.BuildCmd, "submit", submitId),
+		RunCmd:     ReplaceCommand(command.RunCmd, "submit", submitId),
+		DeleteCmd:  ReplaceCommand(command.DeleteCmd, "submit", submitId),
+		WarmUpRuns: WarmUpRuns(language),
+	}
+}
+
+// SubmitProblem 은 채점 후 결과 이벤트를 발행한다. 진행 상황은 JudgeEventBroker 로 구독할 수 있다
+// ctx 는 요청의 trace context 를 잇는 데에만 쓰고, 취소되더라도 채점은 끝까지 진행한다.
+// 같은 submitId 가 채점 중이면 그 결과를 기다리고, 이미 끝났으면 남겨둔 결과를 돌려준다.
+// 같은 submitId 에 코드가 다르면 ErrSubmissionConflict 를 반환한다
+func (service *ProblemService) SubmitProblem(ctx context.Context, dto SubmitProblemDTO) (JudgeResultEnum, int64, int64, error) {
+	return service.trackSubmission(ctx, dto, false)
+}
+
+// ResubmitProblem 은 재채점처럼 같은 제출을 일부러 다시 채점할 때 쓴다.
+// 같은 submitId 가 채점 중이면 끝나기를 기다린 뒤 새로 채점하고, 남겨둔 결과를 바꾼다
+func (service *ProblemService) ResubmitProblem(ctx context.Context, dto SubmitProblemDTO) (JudgeResultEnum, int64, int64, error) {
+	return service.trackSubmission(ctx, dto, true)
+}
+
+// CheckSubmission 은 채점하지 않고 같은 submitId 에 다른 코드가 있는지만 확인한다
+func (service *ProblemService) CheckSubmission(dto SubmitProblemDTO) error {
+	return service.submissions.check(dto)
+}
+
+func (service *ProblemService) trackSubmission(ctx context.Context, dto SubmitProblemDTO, force bool) (JudgeResultEnum, int64, int64, error) {
+	tracker := service.submissions
+
+	submission, owner, err := tracker.acquire(dto, force)
+	if errors.Is(err, ErrSubmissionConflict) {
+		log.WithContext(ctx).Warnw("같은 submitId 에 다른 코드가 제출되었습니다", "submitId", dto.SubmitId)
+		return JudgeUnknown, 0, 0, err
+	}
+	if err != nil {
+		log.WithContext(ctx).Error(err)
+		return JudgeUnknown, 0, 0, err
+	}
+
+	if !owner {
+		log.WithContext(ctx).Infow("같은 제출의 채점 결과를 기다립니다", "submitId", dto.SubmitId)
+		<-submission.done
+		// 이벤트 보관 시간이 지나 결과 이벤트가 없으면 새로 구독한 클라이언트를 위해 다시 발행한다
+		GetJudgeEventBroker().PublishResultOnce("submit", dto.SubmitId, submission.event())
+		return submission.result, submission.usedTime, submission.usedMemory, submission.err
+	}
+
+	// 채점 중 패닉이 나도 같은 제출을 기다리는 요청이 멈추지 않도록 끝난 것으로 표시한 뒤 다시 패닉을 낸다
+	defer func() {
+		if r := recover(); r != nil {
+			tracker.finish(dto.SubmitId, submission, JudgeUnknown, 0, 0, fmt.Errorf("panic while judging: %v", r))
+			panic(r)
+		}
+	}()
+
+	result, usedTime, usedMemory, err := service.submitProblemOnce(ctx, dto)
+	tracker.save(dto.SubmitId, submission, result, usedTime, usedMemory, err)
+	tracker.finish(dto.SubmitId, submission, result, usedTime, usedMemory, err)
+
+	return result, usedTime, usedMemory, err
+}
+
+// RecoverJudge 는 채점을 실행하는 고루틴에서 defer 로 호출해, 패닉이 나도 서버가 죽지 않고 구독자가 결과 이벤트를 받게 한다
+func RecoverJudge(judgeType string, submitId int) {
+	if r := recover(); r != nil {
+		publishJudgePanic(judgeType, submitId, r)
+	}
+}
+
+// recoverJudgeError 는 RecoverJudge 와 같지만, 백그라운드 작업이 채점을 실패로 처리할 수 있도록 복구한 패닉을 err 에 담는다
+func recoverJudgeError(judgeType string, submitId int, err *error) {
+	if r := recover(); r != nil {
+		*err = publishJudgePanic(judgeType, submitId, r)
+	}
+}
+
+func publishJudgePanic(judgeType string, submitId int, r any) error {
+	err := fmt.Errorf("panic while judging: %v", r)
+	log.Error(err, "\n", string(debug.Stack()))
+	GetJudgeEventBroker().Publish(judgeType, submitId, JudgeEventResult, JudgeEvent{Result: JudgeUnknown.String(), Error: err.Error()})
+	return err
+}
+
+func (service *ProblemService) submitProblemOnce(ctx context.Context, dto SubmitProblemDTO) (JudgeResultEnum, int64, int64, error) {
+	GetJudgeEventBroker().Reset("submit", dto.SubmitId)
+
+	workspace := filepath.Join("submit", strconv.Itoa(dto.SubmitId))
+	if err := beginJudge(workspace); err != nil {
+		GetJudgeEventBroker().Publish("submit", dto.SubmitId, JudgeEventResult, JudgeEvent{Result: JudgeUnknown.String(), Error: err.Error()})
+		return JudgeUnknown, 0, 0, err
+	}
+	defer endJudge(workspace)
+
+	ctx, span := tracing.Start(ctx, "SubmitProblem", submissionAttributes(dto.SubmitId, dto.ProblemId, dto.Language)...)
+	ctx = loggers.WithSubmission(ctx, "submit", dto.SubmitId, dto.ProblemId, dto.Language)
+	result, usedTime, usedMemory, err := service.submitProblem(ctx, dto)
+	span.SetAttributes(attribute.String("judge.result", result.String()))
+	tracing.End(span, err)
+
+	metrics.Submissions.WithLabelValues("submit", metrics.LanguageLabel(dto.Language), result.String()).Inc()
+	if result == JudgeUnknown {
+		metrics.UnknownErrors.WithLabelValues("submit", metrics.LanguageLabel(dto.Language)).Inc()
+	}
+
+	GetJudgeEventBroker().Publish("submit", dto.SubmitId, JudgeEventResult, JudgeEvent{
+		Result:     result.String(),
+		Error:      ErrStrIfNotNil(err),
+		UsedTime:   usedTime,
+		UsedMemory: usedMemory,
+	})
+
+	return result, usedTime, usedMemory, err
+}
+
+func (service *ProblemService) submitProblem(ctx context.Context, dto SubmitProblemDTO) (JudgeResultEnum, int64, int64, error) {
 	problemId := dto.ProblemId
 	submitId := dto.SubmitId
 	language := dto.Language
@@ -41,53 +206,129 @@ func (service *ProblemService) SubmitProblem(dto SubmitProblemDTO) (JudgeResultE
 	buildCmd := dto.BuildCmd
 	runCmd := dto.RunCmd
 	deleteCmd := dto.DeleteCmd
+	warmUpRuns := dto.WarmUpRuns
 
+	_, span := tracing.Start(ctx, "GetProblemInfo")
 	problemInfo, err := service.repository.GetProblemInfo(problemId)
+	tracing.End(span, err)
 	if err != nil {
-		log.Error(err)
+		log.WithContext(ctx).Error(err)
+		return JudgeUnknown, 0, 0, err
+	}
+	if err = checkTestData(problemInfo); err != nil {
+		log.WithContext(ctx).Error(err)
 		return JudgeUnknown, 0, 0, err
 	}
-	timeLimit := problemInfo.TimeLimit
-	memoryLimit := problemInfo.MemoryLimit
+	timeLimit := ApplyLimitMultiplier(problemInfo.TimeLimit, TimeLimitMultiplier(language))
+	memoryLimit := ApplyLimitMultiplier(problemInfo.MemoryLimit, MemoryLimitMultiplier(language))
 
-	printSubmitProblemInfo(language, submitId, problemId, code, timeLimit, memoryLimit)
+	printSubmitProblemInfo(ctx, code, timeLimit, memoryLimit)
 
-	if err = saveSubmitTestCases(service, submitId, problemId); err != nil {
-		log.Error(err)
+	testCasesCtx, span := tracing.Start(ctx, "GetTestcases")
+	err = saveSubmitTestCases(testCasesCtx, service, submitId, problemId)
+	tracing.End(span, err)
+	if err != nil {
+		log.WithContext(ctx).Error(err)
 		return JudgeUnknown, 0, 0, err
 	}
 
 	defer func() {
+		if dto.SkipSaveCode {
+			return
+		}
+
 		path := filepath.Join("submits", strconv.Itoa(submitId), "Main."+FileExtension(language))
-		if err = saveCode(service, path, code); err != nil {
-			log.Error(err)
+		saveCodeCtx, span := tracing.Start(ctx, "saveCode")
+		err := saveCode(saveCodeCtx, service, path, code)
+		tracing.End(span, err)
+		if err != nil {
+			log.WithContext(ctx).Error(err)
 			return
 		}
 	}()
 
-	result, err := buildSource(submitId, language, "submit", code, buildCmd)
+	buildCtx, span := tracing.Start(ctx, "buildSource")
+	result, err := buildSource(buildCtx, submitId, language, "submit", code, buildCmd)
+	tracing.End(span, err)
 	if err != nil {
-		log.Error(err)
+		log.WithContext(ctx).Error(err)
 		return result, 0, 0, err
 	}
 
 	defer func() {
-		if err = deleteProgram(language, deleteCmd); err != nil {
-			log.Error(err)
+		if err = deleteProgram(ctx, language, deleteCmd); err != nil {
+			log.WithContext(ctx).Error(err)
 			return
 		}
 	}()
 
-	result, usedTime, usedMemory, err := judgeSubmit(runCmd, submitId, timeLimit, memoryLimit)
+	result, usedTime, usedMemory, err := judgeSubmit(ctx, runCmd, submitId, language, timeLimit, memoryLimit, ParseChecker(problemInfo.Checker), warmUpRuns)
 	if err != nil {
-		log.Error(err)
+		log.WithContext(ctx).Error(err)
 		return result, 0, 0, err
 	}
 
 	return result, usedTime, usedMemory, nil
 }
 
-func (service *ProblemService) RunProblem(dto RunProblemDTO) []RunProblemResult {
+func submissionAttributes(submitId, problemId int, language string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Int("judge.submit_id", submitId),
+		attribute.Int("judge.problem_id", problemId),
+		attribute.String("judge.language", language),
+	}
+}
+
+func NewRunProblemDTO(problemId int, language string, code []byte, testCases []TestCase) RunProblemDTO {
+	submitId := RandomInt(int(math.Pow10(11)), int(math.Pow10(12)-1))
+	command := Commands[language]
+
+	return RunProblemDTO{
+		ProblemId: problemId,
+		SubmitId:  submitId,
+		Language:  language,
+		Code:      code,
+		TestCases: testCases,
+		BuildCmd:  ReplaceCommand(command.BuildCmd, "run", submitId),
+		RunCmd:    ReplaceCommand(command.RunCmd, "run", submitId),
+		DeleteCmd: ReplaceCommand(command.DeleteCmd, "run", submitId),
+	}
+}
+
+// RunProblem 은 실행 후 결과 이벤트를 발행한다. 결과 이벤트의 결과는 처음으로 틀린 테스트 케이스의 결과다
+// ctx 는 요청의 trace context 를 잇는 데에만 쓰고, 취소되더라도 실행은 끝까지 진행한다
+func (service *ProblemService) RunProblem(ctx context.Context, dto RunProblemDTO) []RunProblemResult {
+	workspace := filepath.Join("run", strconv.Itoa(dto.SubmitId))
+	if err := beginJudge(workspace); err != nil {
+		GetJudgeEventBroker().Publish("run", dto.SubmitId, JudgeEventResult, JudgeEvent{Result: JudgeUnknown.String(), Error: err.Error()})
+		return []RunProblemResult{{Result: JudgeUnknown, Error: err}}
+	}
+	defer endJudge(workspace)
+
+	ctx, span := tracing.Start(ctx, "RunProblem", submissionAttributes(dto.SubmitId, dto.ProblemId, dto.Language)...)
+	defer span.End()
+	ctx = loggers.WithSubmission(ctx, "run", dto.SubmitId, dto.ProblemId, dto.Language)
+	results := service.runProblem(ctx, dto)
+
+	event := JudgeEvent{Result: JudgeCorrect.String()}
+	for _, result := range results {
+		if result.Result != JudgeCorrect || result.Error != nil {
+			event = JudgeEvent{Result: result.Result.String(), Error: ErrStrIfNotNil(result.Error)}
+			break
+		}
+	}
+	GetJudgeEventBroker().Publish("run", dto.SubmitId, JudgeEventResult, event)
+	span.SetAttributes(attribute.String("judge.result", event.Result))
+
+	metrics.Submissions.WithLabelValues("run", metrics.LanguageLabel(dto.Language), event.Result).Inc()
+	if event.Result == JudgeUnknown.String() {
+		metrics.UnknownErrors.WithLabelValues("run", metrics.LanguageLabel(dto.Language)).Inc()
+	}
+
+	return results
+}
+
+func (service *ProblemService) runProblem(ctx context.Context, dto RunProblemDTO) []RunProblemResult {
 	problemId := dto.ProblemId
 	submitId := dto.SubmitId
 	language := dto.Language
@@ -97,125 +338,164 @@ func (service *ProblemService) RunProblem(dto RunProblemDTO) []RunProblemResult
 	runCmd := dto.RunCmd
 	deleteCmd := dto.DeleteCmd
 
+	_, span := tracing.Start(ctx, "GetProblemInfo")
 	problemInfo, err := service.repository.GetProblemInfo(problemId)
+	tracing.End(span, err)
 	if err != nil {
-		log.Error(err)
+		log.WithContext(ctx).Error(err)
 		return []RunProblemResult{{Result: JudgeUnknown, Error: err}}
 	}
-	timeLimit := problemInfo.TimeLimit
-	memoryLimit := problemInfo.MemoryLimit
+	timeLimit := ApplyLimitMultiplier(problemInfo.TimeLimit, TimeLimitMultiplier(language))
+	memoryLimit := ApplyLimitMultiplier(problemInfo.MemoryLimit, MemoryLimitMultiplier(language))
 
-	printRunProblemInfo(language, submitId, problemId, code, testCases, timeLimit, memoryLimit)
+	printRunProblemInfo(ctx, code, testCases, timeLimit, memoryLimit)
 
-	if err = saveRunTestCases(submitId, testCases); err != nil {
-		log.Error(err)
+	if err = saveRunTestCases(ctx, submitId, testCases); err != nil {
+		log.WithContext(ctx).Error(err)
 		return []RunProblemResult{{Result: JudgeUnknown, Error: err}}
 	}
 
-	result, err := buildSource(submitId, language, "run", code, buildCmd)
+	buildCtx, span := tracing.Start(ctx, "buildSource")
+	result, err := buildSource(buildCtx, submitId, language, "run", code, buildCmd)
+	tracing.End(span, err)
 	if err != nil {
-		log.Error(err)
+		log.WithContext(ctx).Error(err)
 		return []RunProblemResult{{Result: result, Error: err}}
 	}
 
 	defer func() {
-		if err = deleteProgram(language, deleteCmd); err != nil {
-			log.Error(err)
+		if err = deleteProgram(ctx, language, deleteCmd); err != nil {
+			log.WithContext(ctx).Error(err)
 			return
 		}
 	}()
 
-	results := judgeRun(runCmd, submitId, timeLimit, memoryLimit)
+	results := judgeRun(ctx, runCmd, submitId, language, timeLimit, memoryLimit, ParseChecker(problemInfo.Checker))
 
 	return results
 }
 
-func printSubmitProblemInfo(language string, submitId, problemId int, code []byte, timeLimit, memoryLimit int) {
-	log.Info("--------------------------------")
-	log.Info("언어: ", language)
-	log.Info("제출 번호: ", submitId)
-	log.Info("문제 번호: ", problemId)
-	log.Info("시간 제한: ", timeLimit, "ms")
-	log.Info("메모리 제한: ", memoryLimit, "KB")
-	log.Info("코드 길이: ", len(string(code)), "B")
-	log.Info("제출 코드:\n", string(code))
-}
-
-func printRunProblemInfo(language string, submitId, problemId int, code []byte, testCases []TestCase, timeLimit, memoryLimit int) {
-	log.Info("--------------------------------")
-	log.Info("언어: ", language)
-	log.Info("제출 번호: ", submitId)
-	log.Info("문제 번호: ", problemId)
-	log.Info("시간 제한: ", timeLimit, "ms")
-	log.Info("메모리 제한: ", memoryLimit, "KB")
-	log.Info("코드 길이: ", len(string(code)), "B")
-	log.Info("제출 코드:\n", string(code))
-	log.Info("테스트 케이스:")
-	for i, testCase := range testCases {
-		log.Info(i+1, "번째 테스트 케이스")
+// 제출 코드는 LOG_CODE 가 켜져 있을 때만 남긴다
+func printSubmitProblemInfo(ctx context.Context, code []byte, timeLimit, memoryLimit int) {
+	keysAndValues := []interface{}{"timeLimit", timeLimit, "memoryLimitMB", memoryLimit, "codeLength", len(code)}
+	if loggers.LogCode() {
+		keysAndValues = append(keysAndValues, "code", string(code))
+	}
+	log.WithContext(ctx).Infow("채점 시작", keysAndValues...)
+}
 
-		input := DecodeBase64([]byte(testCase.Input))
-		log.Info("입력:\n", string(input))
+// 테스트 케이스 입출력은 LOG_TESTDATA 가 켜져 있을 때만 남긴다
+func printRunProblemInfo(ctx context.Context, code []byte, testCases []TestCase, timeLimit, memoryLimit int) {
+	keysAndValues := []interface{}{"timeLimit", timeLimit, "memoryLimitMB", memoryLimit, "codeLength", len(code), "testCaseNum", len(testCases)}
+	if loggers.LogCode() {
+		keysAndValues = append(keysAndValues, "code", string(code))
+	}
+	log.WithContext(ctx).Infow("실행 시작", keysAndValues...)
 
+	if !loggers.LogTestData() {
+		return
+	}
+	for i, testCase := range testCases {
+		input := DecodeBase64([]byte(testCase.Input))
 		output := DecodeBase64([]byte(testCase.Output))
-		log.Info("출력:\n", string(output))
+		log.WithContext(ctx).Debugw("테스트 케이스", "testCase", i+1, "input", string(input), "output", string(output))
 	}
 }
 
-func saveSubmitTestCases(service *ProblemService, submitId, problemId int) error {
-	log.Info("--------------------------------")
-	log.Info("테스트 케이스 저장 중...")
+func saveSubmitTestCases(ctx context.Context, service *ProblemService, submitId, problemId int) error {
+	log.WithContext(ctx).Debug("테스트 케이스 저장 중...")
 
-	if err := MakeDir(filepath.Join("submit", strconv.Itoa(submitId), "in")); err != nil {
-		log.Error(err)
+	inputDir := filepath.Join("submit", strconv.Itoa(submitId), "in")
+	if err := MakeDir(inputDir); err != nil {
+		log.WithContext(ctx).Error(err)
 		return err
 	}
 
-	if err := MakeDir(filepath.Join("submit", strconv.Itoa(submitId), "out")); err != nil {
-		log.Error(err)
+	outputDir := filepath.Join("submit", strconv.Itoa(submitId), "out")
+	if err := MakeDir(outputDir); err != nil {
+		log.WithContext(ctx).Error(err)
 		return err
 	}
 
-	//testCases, err := service.repository.GetObjectsInFolder(filepath.Join("testcases", strconv.Itoa(problemId)))
-	testCases, err := service.repository.GetTestcases(problemId)
+	version, err := service.repository.GetTestcasesVersion(problemId)
 	if err != nil {
-		log.Error(err)
+		log.WithContext(ctx).Error(err)
+		return err
+	}
+
+	cacheDir, release, err := service.testCaseCache.Acquire(problemId, version, func(dir string) error {
+		return fetchTestCases(ctx, service, problemId, dir)
+	})
+	if err != nil {
+		log.WithContext(ctx).Error(err)
 		return err
 	}
+	defer release()
 
-	testCaseNum := len(testCases) / 2
-	inputTestCases := testCases[:testCaseNum]
-	outputTestCases := testCases[testCaseNum:]
+	stats := service.testCaseCache.Stats()
+	log.WithContext(ctx).Debugw("테스트 케이스 캐시", "hits", stats.Hits, "misses", stats.Misses, "entries", stats.Entries, "size", stats.Size)
+
+	testCaseNum, err := GetTestCaseNum(filepath.Join(cacheDir, "in"))
+	if err != nil {
+		log.WithContext(ctx).Error(err)
+		return err
+	}
 
+	// 채점하는 코드가 캐시에 있는 파일을 고칠 수 없도록 하드 링크가 아니라 복사한다
 	for i := 0; i < testCaseNum; i++ {
-		inputFilePath := filepath.Join("submit", strconv.Itoa(submitId), "in", strconv.Itoa(i)+".in")
-		if err = os.WriteFile(inputFilePath, inputTestCases[i], 0644); err != nil {
-			log.Error(err)
+		inputFileName := strconv.Itoa(i) + ".in"
+		if err = CopyFile(filepath.Join(cacheDir, "in", inputFileName), filepath.Join(inputDir, inputFileName)); err != nil {
+			log.WithContext(ctx).Error(err)
 			return err
 		}
 
-		outputFilePath := filepath.Join("submit", strconv.Itoa(submitId), "out", strconv.Itoa(i)+".out")
-		if err = os.WriteFile(outputFilePath, outputTestCases[i], 0644); err != nil {
-			log.Error(err)
+		outputFileName := strconv.Itoa(i) + ".out"
+		if err = CopyFile(filepath.Join(cacheDir, "out", outputFileName), filepath.Join(outputDir, outputFileName)); err != nil {
+			log.WithContext(ctx).Error(err)
 			return err
 		}
 	}
 
-	log.Info("테스트 케이스 저장 완료!")
+	log.WithContext(ctx).Debug("테스트 케이스 저장 완료!")
+	return nil
+}
+
+func fetchTestCases(ctx context.Context, service *ProblemService, problemId int, dir string) error {
+	log.WithContext(ctx).Debug("테스트 케이스 캐시 없음, 데이터베이스에서 가져오는 중...")
+
+	if err := MakeDir(filepath.Join(dir, "in")); err != nil {
+		log.WithContext(ctx).Error(err)
+		return err
+	}
+
+	if err := MakeDir(filepath.Join(dir, "out")); err != nil {
+		log.WithContext(ctx).Error(err)
+		return err
+	}
+
+	//_, err := service.repository.SaveObjectsInFolder(filepath.Join("testcases", strconv.Itoa(problemId)), dir)
+	_, span := tracing.Start(ctx, "SaveTestcases")
+	testCaseNum, err := service.repository.SaveTestcases(problemId, filepath.Join(dir, "in"), filepath.Join(dir, "out"))
+	span.SetAttributes(attribute.Int("judge.testcases", testCaseNum))
+	tracing.End(span, err)
+	if err != nil {
+		log.WithContext(ctx).Error(err)
+		return err
+	}
+
 	return nil
 }
 
-func saveRunTestCases(submitId int, testCases []TestCase) error {
-	log.Info("--------------------------------")
-	log.Info("테스트 케이스 저장 중...")
+func saveRunTestCases(ctx context.Context, submitId int, testCases []TestCase) error {
+	log.WithContext(ctx).Debug("테스트 케이스 저장 중...")
 
 	if err := MakeDir(filepath.Join("run", strconv.Itoa(submitId), "in")); err != nil {
-		log.Error(err)
+		log.WithContext(ctx).Error(err)
 		return err
 	}
 
 	if err := MakeDir(filepath.Join("run", strconv.Itoa(submitId), "out")); err != nil {
-		log.Error(err)
+		log.WithContext(ctx).Error(err)
 		return err
 	}
 
@@ -223,239 +503,551 @@ func saveRunTestCases(submitId int, testCases []TestCase) error {
 		inputContents := DecodeBase64([]byte(testCase.Input))
 		inputFilePath := filepath.Join("run", strconv.Itoa(submitId), "in", strconv.Itoa(i)+".in")
 		if err := os.WriteFile(inputFilePath, inputContents, 0644); err != nil {
-			log.Error(err)
+			log.WithContext(ctx).Error(err)
 			return err
 		}
 
 		outputContents := DecodeBase64([]byte(testCase.Output))
 		outputFilePath := filepath.Join("run", strconv.Itoa(submitId), "out", strconv.Itoa(i)+".out")
 		if err := os.WriteFile(outputFilePath, outputContents, 0644); err != nil {
-			log.Error(err)
+			log.WithContext(ctx).Error(err)
 			return err
 		}
 	}
 
-	log.Info("테스트 케이스 저장 완료!")
+	log.WithContext(ctx).Debug("테스트 케이스 저장 완료!")
 	return nil
 }
 
-func saveSourceCode(submitId int, code []byte, language, judgeType string) error {
-	log.Info("--------------------------------")
-	log.Info("소스 코드 저장 중...")
+func saveSourceCode(ctx context.Context, submitId int, code []byte, language, judgeType string) error {
+	log.WithContext(ctx).Debug("소스 코드 저장 중...")
 
 	if err := MakeDir(filepath.Join(judgeType, strconv.Itoa(submitId))); err != nil {
-		log.Error(err)
+		log.WithContext(ctx).Error(err)
 		return err
 	}
 
 	sourceFilePath := filepath.Join(judgeType, strconv.Itoa(submitId), "Main."+FileExtension(language))
 	if err := os.WriteFile(sourceFilePath, code, 0644); err != nil {
-		log.Error(err)
+		log.WithContext(ctx).Error(err)
 		return err
 	}
 
-	log.Info("소스 코드 저장 완료!")
+	log.WithContext(ctx).Debug("소스 코드 저장 완료!")
 	return nil
 }
 
-func buildSource(submitId int, language string, judgeType string, code []byte, buildCmd []string) (JudgeResultEnum, error) {
-	if err := saveSourceCode(submitId, code, language, judgeType); err != nil {
-		log.Error(err)
+func buildSource(ctx context.Context, submitId int, language string, judgeType string, code []byte, buildCmd []string) (JudgeResultEnum, error) {
+	if err := saveSourceCode(ctx, submitId, code, language, judgeType); err != nil {
+		log.WithContext(ctx).Error(err)
 		return JudgeUnknown, err
 	}
 
-	log.Info("--------------------------------")
-	log.Info("소스 코드 빌드 중...")
+	broker := GetJudgeEventBroker()
+	broker.Publish(judgeType, submitId, JudgeEventCompileStart, JudgeEvent{})
+
+	startTime := time.Now()
+	result, err := compileSource(ctx, language, buildCmd)
+	if len(buildCmd) > 0 {
+		metrics.CompileDuration.WithLabelValues(metrics.LanguageLabel(language), result.String()).Observe(time.Since(startTime).Seconds())
+	}
+	broker.Publish(judgeType, submitId, JudgeEventCompileFinish, JudgeEvent{
+		Result: result.String(),
+		Error:  ErrStrIfNotNil(err),
+	})
+
+	return result, err
+}
+
+func compileSource(ctx context.Context, language string, buildCmd []string) (JudgeResultEnum, error) {
+	log.WithContext(ctx).Debug("소스 코드 빌드 중...")
 	if len(buildCmd) == 0 {
-		log.Info(language + " 빌드 생략")
+		log.WithContext(ctx).Debug(language + " 빌드 생략")
 		return JudgeCorrect, nil
 	}
 
 	var stderr bytes.Buffer
-	cmd := exec.Command(buildCmd[0], buildCmd[1:]...)
+	cmd := exec.CommandContext(judgeContext, buildCmd[0], buildCmd[1:]...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
+		if isKilled() {
+			log.WithContext(ctx).Error(ErrDraining)
+			return JudgeUnknown, ErrDraining
+		}
+
 		compileError := fmt.Errorf("\n%w\n%s", err, stderr.String())
-		log.Error(compileError)
+		log.WithContext(ctx).Error(compileError)
 		return JudgeCompileError, compileError
 	}
 
-	log.Info("소스 코드 빌드 완료!")
+	log.WithContext(ctx).Debug("소스 코드 빌드 완료!")
 	return JudgeCorrect, nil
 }
 
-func judgeSubmit(runCmd []string, submitId int, timeLimit, memoryLimit int) (JudgeResultEnum, int64, int64, error) {
+type testCaseResult struct {
+	isCorrect  bool
+	result     JudgeResultEnum
+	usedTime   int64
+	usedMemory int64
+	err        error
+}
+
+// 사용 시간과 메모리는 모든 테스트 케이스 중 최댓값으로 보고한다
+func judgeSubmit(ctx context.Context, runCmd []string, submitId int, language string, timeLimit, memoryLimit int, checker CheckerEnum, warmUpRuns int) (JudgeResultEnum, int64, int64, error) {
 	testCaseNum, err := GetTestCaseNum(filepath.Join("submit", strconv.Itoa(submitId), "in"))
 	if err != nil {
-		log.Error(err)
+		log.WithContext(ctx).Error(err)
 		return JudgeUnknown, 0, 0, err
 	}
-	if testCaseNum <= 1 {
+	if testCaseNum == 0 {
 		return JudgeUnknown, 0, 0, errors.New("not enough testcases")
 	}
 
+	resDir := filepath.Join("submit", strconv.Itoa(submitId), "res")
+	if err = MakeDir(resDir); err != nil {
+		log.WithContext(ctx).Error(err)
+		return JudgeUnknown, 0, 0, err
+	}
+	// 출력 파일은 테스트 케이스마다 출력 제한까지 커질 수 있으므로 채점이 끝나면 지운다
+	defer os.RemoveAll(resDir)
+
+	warmUpProgram(ctx, runCmd, submitId, timeLimit, memoryLimit, warmUpRuns)
+
+	var testCaseResults []testCaseResult
+	if isParallelJudge() {
+		testCaseResults = judgeSubmitParallel(ctx, runCmd, submitId, language, testCaseNum, timeLimit, memoryLimit, checker)
+	} else {
+		testCaseResults = judgeSubmitSequential(ctx, runCmd, submitId, language, testCaseNum, timeLimit, memoryLimit, checker)
+	}
+
 	judgeResults := make([]bool, 0, testCaseNum)
 	usedTimes := make([]int64, 0, testCaseNum)
 	usedMemories := make([]int64, 0, testCaseNum)
 
-	for i := 0; i < testCaseNum; i++ {
-		log.Info("--------------------------------")
-		log.Info(i+1, "번째 테스트케이스 실행")
+	for _, testCaseResult := range testCaseResults {
+		if testCaseResult.err != nil {
+			log.WithContext(ctx).Error(testCaseResult.err)
+			return testCaseResult.result, 0, 0, testCaseResult.err
+		}
+
+		judgeResults = append(judgeResults, testCaseResult.isCorrect)
+		usedTimes = append(usedTimes, testCaseResult.usedTime)
+		usedMemories = append(usedMemories, testCaseResult.usedMemory)
+	}
+
+	usedTime := Max(usedTimes)
+	usedMemory := Max(usedMemories)
+
+	if !All(judgeResults) {
+		printJudgeSubmitResult(ctx, false, usedTime, usedMemory)
+		return JudgeWrong, usedTime, usedMemory, nil
+	}
 
-		inputContents, err := os.ReadFile(filepath.Join("submit", strconv.Itoa(submitId), "in", strconv.Itoa(i)+".in"))
+	printJudgeSubmitResult(ctx, true, usedTime, usedMemory)
+	return JudgeCorrect, usedTime, usedMemory, nil
+}
+
+// 첫 번째 테스트 케이스로 프로그램을 미리 실행해 본다. 결과는 채점에 쓰지 않는다
+func warmUpProgram(ctx context.Context, runCmd []string, submitId int, timeLimit, memoryLimit int, warmUpRuns int) {
+	inputFilePath := filepath.Join("submit", strconv.Itoa(submitId), "in", "0.in")
+	executeFilePath := filepath.Join("submit", strconv.Itoa(submitId), "res", "warmup.res")
+	defer os.Remove(executeFilePath)
+
+	for i := 0; i < warmUpRuns; i++ {
+		log.WithContext(ctx).Debugw("워밍업 실행", "run", i+1)
+
+		executeCtx, span := tracing.Start(ctx, "executeProgram", attribute.Bool("judge.warmup", true))
+		_, _, _, err := executeProgram(executeCtx, runCmd, inputFilePath, executeFilePath, timeLimit, memoryLimit)
+		tracing.End(span, err)
 		if err != nil {
-			log.Error(err)
-			return JudgeUnknown, 0, 0, err
+			log.WithContext(ctx).Error(err)
 		}
+	}
+}
 
-		result, executeContents, usedTime, usedMemory, err := executeProgram(runCmd, inputContents, timeLimit, memoryLimit)
-		if err != nil {
-			log.Error(err)
-			return result, 0, 0, err
+// 실패한 테스트 케이스가 나오면 거기서 멈춘다
+func judgeSubmitSequential(ctx context.Context, runCmd []string, submitId int, language string, testCaseNum int, timeLimit, memoryLimit int, checker CheckerEnum) []testCaseResult {
+	testCaseResults := make([]testCaseResult, 0, testCaseNum)
+	for i := 0; i < testCaseNum; i++ {
+		testCaseResult := judgeSubmitTestCase(ctx, runCmd, submitId, language, i, testCaseNum, timeLimit, memoryLimit, checker)
+		testCaseResults = append(testCaseResults, testCaseResult)
+		if testCaseResult.err != nil {
+			break
 		}
+	}
 
-		outputContents, err := os.ReadFile(filepath.Join("submit", strconv.Itoa(submitId), "out", strconv.Itoa(i)+".out"))
-		if err != nil {
-			log.Error(err)
-			return JudgeUnknown, 0, 0, err
+	return testCaseResults
+}
+
+// 테스트 케이스를 동시에 실행하되, 실패가 나오면 아직 시작하지 않은 테스트 케이스는 건너뛴다
+func judgeSubmitParallel(ctx context.Context, runCmd []string, submitId int, language string, testCaseNum int, timeLimit, memoryLimit int, checker CheckerEnum) []testCaseResult {
+	testCaseResults := make([]testCaseResult, testCaseNum)
+
+	indexes := make(chan int, testCaseNum)
+	for i := 0; i < testCaseNum; i++ {
+		indexes <- i
+	}
+	close(indexes)
+
+	workers := min(testCaseNum, cap(getExecutionSlots()))
+
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if failed.Load() {
+					testCaseResults[i] = testCaseResult{result: JudgeUnknown, err: errSkippedTestCase}
+					continue
+				}
+
+				testCaseResults[i] = judgeSubmitTestCase(ctx, runCmd, submitId, language, i, testCaseNum, timeLimit, memoryLimit, checker)
+				if testCaseResults[i].err != nil {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 실패가 기록되는 사이에 그보다 앞선 테스트 케이스가 건너뛰어질 수 있으므로,
+	// 건너뛴 결과는 빼고 처음 실패한 테스트 케이스까지만 반환한다
+	judgedResults := make([]testCaseResult, 0, testCaseNum)
+	for _, testCaseResult := range testCaseResults {
+		if errors.Is(testCaseResult.err, errSkippedTestCase) {
+			continue
 		}
 
-		log.Info("사용 시간: ", usedTime, "ms")
-		log.Info("사용 메모리: ", usedMemory, "KB")
-		judgeResult := checkDifference(executeContents, outputContents)
-		judgeResults = append(judgeResults, judgeResult)
-		usedTimes = append(usedTimes, usedTime)
-		usedMemories = append(usedMemories, usedMemory)
+		judgedResults = append(judgedResults, testCaseResult)
+		if testCaseResult.err != nil {
+			break
+		}
 	}
 
-	usedTime := Sum(usedTimes[1:]) / (int64(testCaseNum) - 1)
-	usedMemory := Sum(usedMemories) / int64(testCaseNum)
+	return judgedResults
+}
 
-	if !All(judgeResults) {
-		printJudgeSubmitResult(false, usedTime, usedMemory)
-		return JudgeWrong, usedTime, usedMemory, nil
+func judgeSubmitTestCase(ctx context.Context, runCmd []string, submitId int, language string, i, testCaseNum int, timeLimit, memoryLimit int, checker CheckerEnum) testCaseResult {
+	testCaseResult := executeSubmitTestCase(ctx, runCmd, submitId, i, timeLimit, memoryLimit, checker)
+
+	result := testCaseResult.result
+	if testCaseResult.err == nil && !testCaseResult.isCorrect {
+		result = JudgeWrong
+	}
+	metrics.TestCaseDuration.WithLabelValues("submit", metrics.LanguageLabel(language), result.String()).Observe(float64(testCaseResult.usedTime) / 1000)
+	log.WithContext(ctx).Infow("테스트 케이스 결과", "testCase", i+1, "testCaseNum", testCaseNum, "result", result.String(), "usedTime", testCaseResult.usedTime, "usedMemory", testCaseResult.usedMemory)
+	GetJudgeEventBroker().Publish("submit", submitId, JudgeEventTestCase, JudgeEvent{
+		TestCase:    i + 1,
+		TestCaseNum: testCaseNum,
+		Result:      result.String(),
+		Error:       ErrStrIfNotNil(testCaseResult.err),
+		UsedTime:    testCaseResult.usedTime,
+		UsedMemory:  testCaseResult.usedMemory,
+	})
+
+	return testCaseResult
+}
+
+func executeSubmitTestCase(ctx context.Context, runCmd []string, submitId, i int, timeLimit, memoryLimit int, checker CheckerEnum) testCaseResult {
+	log.WithContext(ctx).Debugw("테스트 케이스 실행", "testCase", i+1)
+
+	inputFilePath := filepath.Join("submit", strconv.Itoa(submitId), "in", strconv.Itoa(i)+".in")
+	executeFilePath := filepath.Join("submit", strconv.Itoa(submitId), "res", strconv.Itoa(i)+".res")
+	defer os.Remove(executeFilePath)
+	executeCtx, span := tracing.Start(ctx, "executeProgram", attribute.Int("judge.testcase", i+1))
+	result, usedTime, usedMemory, err := executeProgram(executeCtx, runCmd, inputFilePath, executeFilePath, timeLimit, memoryLimit)
+	span.SetAttributes(
+		attribute.String("judge.result", result.String()),
+		attribute.Int64("judge.used_time_ms", usedTime),
+		attribute.Int64("judge.used_memory_kb", usedMemory),
+	)
+	tracing.End(span, err)
+	if err != nil {
+		log.WithContext(ctx).Error(err)
+		return testCaseResult{result: result, usedTime: usedTime, usedMemory: usedMemory, err: err}
 	}
 
-	printJudgeSubmitResult(true, usedTime, usedMemory)
-	return JudgeCorrect, usedTime, usedMemory, nil
+	outputFilePath := filepath.Join("submit", strconv.Itoa(submitId), "out", strconv.Itoa(i)+".out")
+
+	checkCtx, span := tracing.Start(ctx, "checkDifference", attribute.Int("judge.testcase", i+1))
+	isCorrect, err := checkDifference(checkCtx, checker, executeFilePath, outputFilePath)
+	tracing.End(span, err)
+	if err != nil {
+		log.WithContext(ctx).Error(err)
+		return testCaseResult{result: JudgeUnknown, err: err}
+	}
+
+	return testCaseResult{
+		isCorrect:  isCorrect,
+		result:     JudgeCorrect,
+		usedTime:   usedTime,
+		usedMemory: usedMemory,
+	}
 }
 
-func printJudgeSubmitResult(isCorrect bool, usedTime, usedMemory int64) {
-	log.Info("--------------------------------")
+func printJudgeSubmitResult(ctx context.Context, isCorrect bool, usedTime, usedMemory int64) {
+	result := JudgeWrong
 	if isCorrect {
-		log.Info("문제를 맞췄습니다!")
-	} else {
-		log.Info("문제를 맞추지 못했습니다.")
+		result = JudgeCorrect
 	}
-	log.Info("평균 사용 시간: ", usedTime, "ms")
-	log.Info("평균 사용 메모리: ", usedMemory, "KB")
+	log.WithContext(ctx).Infow("채점 완료", "result", result.String(), "usedTime", usedTime, "usedMemory", usedMemory)
 }
 
-func judgeRun(runCmd []string, submitId int, timeLimit, memoryLimit int) []RunProblemResult {
+func judgeRun(ctx context.Context, runCmd []string, submitId int, language string, timeLimit, memoryLimit int, checker CheckerEnum) []RunProblemResult {
 	testCaseNum, err := GetTestCaseNum(filepath.Join("run", strconv.Itoa(submitId), "in"))
 	if err != nil {
-		log.Error(err)
+		log.WithContext(ctx).Error(err)
+		return []RunProblemResult{{Result: JudgeUnknown, Error: err}}
+	}
+
+	resDir := filepath.Join("run", strconv.Itoa(submitId), "res")
+	if err = MakeDir(resDir); err != nil {
+		log.WithContext(ctx).Error(err)
 		return []RunProblemResult{{Result: JudgeUnknown, Error: err}}
 	}
+	defer os.RemoveAll(resDir)
 
 	results := make([]RunProblemResult, 0, testCaseNum)
 
 	for i := 0; i < testCaseNum; i++ {
-		log.Info("--------------------------------")
-		log.Info(i+1, "번째 테스트케이스 실행")
-
-		inputContents, err := os.ReadFile(filepath.Join("run", strconv.Itoa(submitId), "in", strconv.Itoa(i)+".in"))
-		if err != nil {
-			log.Error(err)
-			return []RunProblemResult{{Result: JudgeUnknown, Error: err}}
+		result := judgeRunTestCase(ctx, runCmd, submitId, language, i, testCaseNum, timeLimit, memoryLimit, checker)
+		if result.Error != nil {
+			log.WithContext(ctx).Error(result.Error)
+			return []RunProblemResult{result}
 		}
 
-		result, executeContents, usedTime, usedMemory, err := executeProgram(runCmd, inputContents, timeLimit, memoryLimit)
-		if err != nil {
-			log.Error(err)
-			return []RunProblemResult{{Result: result, Error: err}}
-		}
+		results = append(results, result)
+	}
 
-		outputContents, err := os.ReadFile(filepath.Join("run", strconv.Itoa(submitId), "out", strconv.Itoa(i)+".out"))
-		if err != nil {
-			log.Error(err)
-			return []RunProblemResult{{Result: JudgeUnknown, Error: err}}
-		}
+	return results
+}
 
-		log.Info("사용 시간: ", usedTime, "ms")
-		log.Info("사용 메모리: ", usedMemory, "KB")
-		isSame := checkDifference(executeContents, outputContents)
-		result = JudgeWrong
-		if isSame {
-			result = JudgeCorrect
-		}
-		results = append(results, RunProblemResult{Result: result, Output: string(EncodeBase64(executeContents))})
+func judgeRunTestCase(ctx context.Context, runCmd []string, submitId int, language string, i, testCaseNum int, timeLimit, memoryLimit int, checker CheckerEnum) RunProblemResult {
+	result, usedTime, usedMemory := executeRunTestCase(ctx, runCmd, submitId, i, timeLimit, memoryLimit, checker)
+	metrics.TestCaseDuration.WithLabelValues("run", metrics.LanguageLabel(language), result.Result.String()).Observe(float64(usedTime) / 1000)
+	log.WithContext(ctx).Infow("테스트 케이스 결과", "testCase", i+1, "testCaseNum", testCaseNum, "result", result.Result.String(), "usedTime", usedTime, "usedMemory", usedMemory)
+
+	GetJudgeEventBroker().Publish("run", submitId, JudgeEventTestCase, JudgeEvent{
+		TestCase:    i + 1,
+		TestCaseNum: testCaseNum,
+		Result:      result.Result.String(),
+		Error:       ErrStrIfNotNil(result.Error),
+		Output:      result.Output,
+		UsedTime:    usedTime,
+		UsedMemory:  usedMemory,
+	})
+
+	return result
+}
+
+func executeRunTestCase(ctx context.Context, runCmd []string, submitId, i int, timeLimit, memoryLimit int, checker CheckerEnum) (RunProblemResult, int64, int64) {
+	log.WithContext(ctx).Debugw("테스트 케이스 실행", "testCase", i+1)
+
+	inputFilePath := filepath.Join("run", strconv.Itoa(submitId), "in", strconv.Itoa(i)+".in")
+	executeFilePath := filepath.Join("run", strconv.Itoa(submitId), "res", strconv.Itoa(i)+".res")
+	defer os.Remove(executeFilePath)
+	executeCtx, span := tracing.Start(ctx, "executeProgram", attribute.Int("judge.testcase", i+1))
+	result, usedTime, usedMemory, err := executeProgram(executeCtx, runCmd, inputFilePath, executeFilePath, timeLimit, memoryLimit)
+	span.SetAttributes(
+		attribute.String("judge.result", result.String()),
+		attribute.Int64("judge.used_time_ms", usedTime),
+		attribute.Int64("judge.used_memory_kb", usedMemory),
+	)
+	tracing.End(span, err)
+	if err != nil {
+		log.WithContext(ctx).Error(err)
+		return RunProblemResult{Result: result, Error: err}, usedTime, usedMemory
 	}
 
-	return results
+	outputFilePath := filepath.Join("run", strconv.Itoa(submitId), "out", strconv.Itoa(i)+".out")
+
+	checkCtx, span := tracing.Start(ctx, "checkDifference", attribute.Int("judge.testcase", i+1))
+	isSame, err := checkDifference(checkCtx, checker, executeFilePath, outputFilePath)
+	tracing.End(span, err)
+	if err != nil {
+		log.WithContext(ctx).Error(err)
+		return RunProblemResult{Result: JudgeUnknown, Error: err}, 0, 0
+	}
+
+	// 출력 전체를 응답에 싣지 않도록 앞부분만 돌려준다
+	executeContents, err := ReadFilePreview(executeFilePath, runOutputPreviewSize+1)
+	if err != nil {
+		log.WithContext(ctx).Error(err)
+		return RunProblemResult{Result: JudgeUnknown, Error: err}, 0, 0
+	}
+
+	truncated := len(executeContents) > runOutputPreviewSize
+	if truncated {
+		executeContents = executeContents[:runOutputPreviewSize]
+	} else {
+		executeContents = TrimAllTrailingWhitespace(executeContents)
+	}
+
+	result = JudgeWrong
+	if isSame {
+		result = JudgeCorrect
+	}
+	return RunProblemResult{Result: result, Output: string(EncodeBase64(executeContents)), OutputTruncated: truncated}, usedTime, usedMemory
 }
 
-func executeProgram(runCmd []string, inputContents []byte, timeLimit, memoryLimit int) (JudgeResultEnum, []byte, int64, int64, error) {
-	log.Info("프로그램 실행 중...")
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeLimit)*time.Millisecond)
+// 입력은 파일에서 바로 표준 입력으로 넘기고, 출력도 파일로 바로 받는다
+// 실행 슬롯을 하나 잡아 동시에 도는 프로그램 수를 코어 수로 제한해서 다른 실행과 시간이 섞이지 않게 한다
+func executeProgram(ctx context.Context, runCmd []string, inputFilePath, executeFilePath string, timeLimit, memoryLimit int) (JudgeResultEnum, int64, int64, error) {
+	result, usedTime, usedMemory, _, err := executeProgramWithStderr(ctx, runCmd, inputFilePath, executeFilePath, timeLimit, memoryLimit)
+	return result, usedTime, usedMemory, err
+}
+
+// 문제의 메모리 제한은 MB 단위로 저장하고, UsedMemory 로 잰 KB 와 비교할 때만 바꾼다
+func memoryLimitKB(memoryLimit int) int64 {
+	return int64(memoryLimit) << 10
+}
+
+// executeProgramWithStderr 는 검증기처럼 표준 에러의 메시지가 필요한 프로그램을 실행할 때 쓴다.
+// 실행 시간과 메모리는 시간 초과나 런타임 에러로 끝나도 잰 값을 반환한다.
+// memoryLimit(MB) 는 실행 중에 막지 않고, 끝난 뒤에 잰 최대 RSS 가 넘었으면 MEMORY_OUT 으로 본다. 0 이면 확인하지 않는다
+func executeProgramWithStderr(ctx context.Context, runCmd []string, inputFilePath, executeFilePath string, timeLimit, memoryLimit int) (JudgeResultEnum, int64, int64, string, error) {
+	cpu := acquireExecutionSlot()
+	defer releaseExecutionSlot(cpu)
+
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("judge.cpu", cpu))
+	log.WithContext(ctx).Debugw("프로그램 실행", "cpu", cpu)
+	if isKilled() {
+		return JudgeUnknown, 0, 0, "", ErrDraining
+	}
+
+	runCtx, cancel := context.WithTimeout(judgeContext, time.Duration(timeLimit)*time.Millisecond)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, runCmd[0], runCmd[1:]...)
-	cmd.Stdin = bytes.NewReader(inputContents)
+	inputFile, err := os.Open(inputFilePath)
+	if err != nil {
+		log.WithContext(ctx).Error(err)
+		return JudgeUnknown, 0, 0, "", err
+	}
+	defer inputFile.Close()
 
-	var outputBuffer bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &outputBuffer
-	cmd.Stderr = &stderr
+	executeFile, err := os.Create(executeFilePath)
+	if err != nil {
+		log.WithContext(ctx).Error(err)
+		return JudgeUnknown, 0, 0, "", err
+	}
+	defer executeFile.Close()
 
-	if err := cmd.Start(); err != nil {
-		log.Error(err)
-		return JudgeRuntimeError, nil, 0, 0, err
+	cmd := exec.CommandContext(runCtx, runCmd[0], runCmd[1:]...)
+	cmd.Stdin = inputFile
+
+	var stderr bytes.Buffer
+	stdout := NewLimitedWriter(executeFile, outputLimit(), cancel)
+	cmd.Stdout = stdout
+	cmd.Stderr = NewLimitedWriter(&stderr, stderrLimit, nil)
+	// 출력을 파이프로 받으므로, 자식 프로세스가 파이프를 쥐고 남아 있어도 시간 제한이 지나면 기다리지 않는다
+	cmd.WaitDelay = waitDelay
+
+	if err = startProgram(cmd, cpu); err != nil {
+		log.WithContext(ctx).Error(err)
+		return JudgeRuntimeError, 0, 0, "", err
 	}
 
 	startTime := time.Now()
-	err := cmd.Wait()
+	err = cmd.Wait()
 	usedTime := time.Since(startTime).Milliseconds()
+	usedMemory := UsedMemory(cmd.ProcessState)
+
+	if isKilled() {
+		log.WithContext(ctx).Error(ErrDraining)
+		return JudgeUnknown, 0, 0, "", ErrDraining
+	}
+
+	// 메모리를 넘게 쓰다가 시간 초과나 런타임 에러로 끝난 것도 MEMORY_OUT 으로 본다
+	if memoryLimit > 0 && usedMemory > memoryLimitKB(memoryLimit) {
+		log.WithContext(ctx).Errorw(ErrMemoryLimitExceeded.Error(), "usedMemoryKB", usedMemory, "memoryLimitMB", memoryLimit)
+		return JudgeMemoryOut, usedTime, usedMemory, stderr.String(), ErrMemoryLimitExceeded
+	}
+
+	if stdout.Exceeded() {
+		log.WithContext(ctx).Error(ErrOutputLimitExceeded)
+		return JudgeRuntimeError, usedTime, usedMemory, stderr.String(), ErrOutputLimitExceeded
+	}
 
-	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-		log.Error(ctx.Err().Error())
-		return JudgeTimeOut, nil, 0, 0, ctx.Err()
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		log.WithContext(ctx).Error(runCtx.Err().Error())
+		return JudgeTimeOut, usedTime, usedMemory, stderr.String(), runCtx.Err()
 	}
 
 	if err != nil {
 		runtimeError := fmt.Errorf("\n%w\n%s", err, stderr.String())
-		log.Error(runtimeError)
-		return JudgeRuntimeError, nil, 0, 0, err
+		log.WithContext(ctx).Error(runtimeError)
+		return JudgeRuntimeError, usedTime, usedMemory, stderr.String(), err
 	}
 
-	output := outputBuffer.Bytes()
-	output = TrimAllTrailingWhitespace(output)
-
-	return JudgeCorrect, output, usedTime, 0, nil
+	return JudgeCorrect, usedTime, usedMemory, stderr.String(), nil
 }
 
-func checkDifference(executeContents, outputContents []byte) bool {
-	log.Info("예상 결과\n", outputContents, "\n", string(outputContents))
-	log.Info("실제 결과\n", executeContents, "\n", string(executeContents))
+// 두 파일을 checker 에 맞게 스트리밍으로 비교한다
+// 예상/실제 결과의 앞부분은 LOG_TESTDATA 가 켜져 있을 때만 남긴다
+func checkDifference(ctx context.Context, checker CheckerEnum, executeFilePath, outputFilePath string) (bool, error) {
+	if loggers.LogTestData() {
+		outputPreview, err := ReadFilePreview(outputFilePath, previewSize)
+		if err != nil {
+			log.WithContext(ctx).Error(err)
+			return false, err
+		}
 
-	log.Info("결과를 비교 중...")
-	if !bytes.Equal(executeContents, outputContents) {
-		log.Info("결과가 일치하지 않습니다.")
-		return false
+		executePreview, err := ReadFilePreview(executeFilePath, previewSize)
+		if err != nil {
+			log.WithContext(ctx).Error(err)
+			return false, err
+		}
+
+		log.WithContext(ctx).Debugw("결과 비교", "expected", string(outputPreview), "actual", string(executePreview))
+	}
+
+	executeFile, err := os.Open(executeFilePath)
+	if err != nil {
+		log.WithContext(ctx).Error(err)
+		return false, err
 	}
+	defer executeFile.Close()
 
-	log.Info("결과가 일치합니다!")
-	return true
+	outputFile, err := os.Open(outputFilePath)
+	if err != nil {
+		log.WithContext(ctx).Error(err)
+		return false, err
+	}
+	defer outputFile.Close()
+
+	log.WithContext(ctx).Debugw("결과를 비교 중...", "checker", checker.String())
+	var isSame bool
+	switch checker {
+	case CheckerTokens:
+		isSame, err = EqualTokens(executeFile, outputFile)
+	case CheckerLines:
+		isSame, err = EqualLineTokens(executeFile, outputFile)
+	default:
+		isSame, err = EqualIgnoringTrailingWhitespace(executeFile, outputFile)
+	}
+	if err != nil {
+		log.WithContext(ctx).Error(err)
+		return false, err
+	}
+
+	if !isSame {
+		log.WithContext(ctx).Debug("결과가 일치하지 않습니다.")
+		return false, nil
+	}
+
+	log.WithContext(ctx).Debug("결과가 일치합니다!")
+	return true, nil
 }
 
-func deleteProgram(language string, deleteCmd []string) error {
-	log.Info("--------------------------------")
-	log.Info("생성된 실행 파일 삭제 중...")
+func deleteProgram(ctx context.Context, language string, deleteCmd []string) error {
+	log.WithContext(ctx).Debug("생성된 실행 파일 삭제 중...")
 
 	if len(deleteCmd) == 0 {
-		log.Info(language + " 삭제 생략")
+		log.WithContext(ctx).Debug(language + " 삭제 생략")
 		return nil
 	}
 
@@ -466,23 +1058,22 @@ func deleteProgram(language string, deleteCmd []string) error {
 
 	if err := cmd.Run(); err != nil {
 		deleteError := fmt.Errorf("\n%w\n%s", err, stderr.String())
-		log.Error(deleteError)
+		log.WithContext(ctx).Error(deleteError)
 		return deleteError
 	}
 
-	log.Info("실행 파일 삭제 완료!")
+	log.WithContext(ctx).Debug("실행 파일 삭제 완료!")
 	return nil
 }
 
-func saveCode(service *ProblemService, path string, code []byte) error {
-	log.Info("--------------------------------")
-	log.Info("오브젝트 스토리지에 제출 코드 저장 중...")
+func saveCode(ctx context.Context, service *ProblemService, path string, code []byte) error {
+	log.WithContext(ctx).Debug("오브젝트 스토리지에 제출 코드 저장 중...")
 
 	if err := service.repository.SaveCode(path, EncodeBase64(code)); err != nil {
-		log.Error(err)
+		log.WithContext(ctx).Error(err)
 		return err
 	}
 
-	log.Info("오브젝트 스토리지에 제출 코드 저장 완료!")
+	log.WithContext(ctx).Debug("오브젝트 스토리지에 제출 코드 저장 완료!")
 	return nil
 }
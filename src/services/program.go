@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	. "leita/src/commands"
+	. "leita/src/entities"
+	"leita/src/repositories"
+	. "leita/src/utils"
+)
+
+// writeProblemResources 는 testlib.h 처럼 검증기, 생성기, 풀이가 함께 쓰는 파일을 소스와 같은 디렉터리에 쓴다
+func writeProblemResources(repository *repositories.ProblemRepository, manifest *ProblemManifest, dir string) error {
+	for _, resource := range manifest.Resources {
+		content, err := repository.GetObject(resource.Path)
+		if err != nil {
+			return err
+		}
+		if err = os.WriteFile(filepath.Join(dir, filepath.Base(resource.Path)), content, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buildProblemProgram 은 오브젝트 스토리지에 있는 검증기, 생성기, 풀이를 {judgeType}/{id} 에서 빌드하고 실행 명령을 반환한다.
+// 빌드하지 못한 이유는 message 로, 저장소 에러나 드레인 중인 것만 err 로 반환한다
+func buildProblemProgram(ctx context.Context, repository *repositories.ProblemRepository, judgeType string, id int, file PackageFile) ([]string, string, error) {
+	if !IsSupported(file.Language) {
+		return nil, fmt.Sprintf("language %q is not supported on this node", file.Language), nil
+	}
+
+	code, err := repository.GetObject(file.Path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	command := Commands[file.Language]
+	buildCmd := ReplaceCommand(command.BuildCmd, judgeType, id)
+	runCmd := ReplaceCommand(command.RunCmd, judgeType, id)
+
+	if result, err := buildSource(ctx, id, file.Language, judgeType, code, buildCmd); result != JudgeCorrect {
+		if errors.Is(err, ErrDraining) {
+			return nil, "", err
+		}
+		return nil, result.String() + ": " + ErrStrIfNotNil(err), nil
+	}
+
+	return runCmd, "", nil
+}
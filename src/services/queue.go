@@ -0,0 +1,73 @@
+package services
+
+import (
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/entities"
+	"leita/src/repositories"
+)
+
+// QueueService 는 채점 작업을 큐에 넣고 상태를 조회한다
+type QueueService struct {
+	queue repositories.JudgeQueue
+}
+
+func NewQueueService() (*QueueService, error) {
+	queue, err := repositories.NewJudgeQueue()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &QueueService{
+		queue: queue,
+	}, nil
+}
+
+func (service *QueueService) Enqueue(problemId, submitId int, language string, code []byte, callbackUrl string) (JudgeJobResponse, error) {
+	job := JudgeJob{
+		SubmitId:    submitId,
+		ProblemId:   problemId,
+		Language:    language,
+		Code:        code,
+		CallbackUrl: callbackUrl,
+		Status:      JudgeJobQueued.String(),
+	}
+
+	jobId, err := service.queue.Enqueue(job)
+	if err != nil {
+		log.Error(err)
+		return JudgeJobResponse{}, err
+	}
+	job.Id = jobId
+
+	return judgeJobResponse(job), nil
+}
+
+// 없는 작업이면 false 를 반환한다
+func (service *QueueService) GetJob(jobId int64) (JudgeJobResponse, bool, error) {
+	job, err := service.queue.GetJob(jobId)
+	if err != nil {
+		log.Error(err)
+		return JudgeJobResponse{}, false, err
+	}
+	if job == nil {
+		return JudgeJobResponse{}, false, nil
+	}
+
+	return judgeJobResponse(*job), true, nil
+}
+
+func judgeJobResponse(job JudgeJob) JudgeJobResponse {
+	return JudgeJobResponse{
+		JobId:      job.Id,
+		SubmitId:   job.SubmitId,
+		ProblemId:  job.ProblemId,
+		Language:   job.Language,
+		Status:     job.Status,
+		Attempts:   job.Attempts,
+		Result:     job.Result,
+		UsedTime:   job.UsedTime,
+		UsedMemory: job.UsedMemory,
+		Error:      job.Error,
+	}
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	. "leita/src/commands"
+	. "leita/src/entities"
+	"leita/src/repositories"
+	. "leita/src/utils"
+)
+
+// 끝난 재채점 작업을 조회할 수 있도록 남겨두는 시간
+const rejudgeJobRetention = time.Hour
+
+// 조건 없이 요청하면 모든 제출을 재채점하게 되므로 받지 않는다
+var ErrEmptyRejudgeFilter = errors.New("rejudge requires at least one filter")
+
+type RejudgeService struct {
+	problemService   *ProblemService
+	submitRepository *repositories.SubmitRepository
+
+	mutex sync.Mutex
+	jobs  map[string]*rejudgeJob
+
+	writeBack bool
+}
+
+type rejudgeJob struct {
+	mutex      sync.Mutex
+	id         string
+	dryRun     bool
+	status     RejudgeStatusEnum
+	total      int
+	done       int
+	changes    []RejudgeChange
+	unchanged  int
+	failed     []RejudgeError
+	finishedAt time.Time
+}
+
+func NewRejudgeService() (*RejudgeService, error) {
+	problemService, err := NewProblemService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	submitRepository, err := repositories.NewSubmitRepository()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &RejudgeService{
+		problemService:   problemService,
+		submitRepository: submitRepository,
+		jobs:             make(map[string]*rejudgeJob),
+		writeBack:        isSubmitWriteBack(),
+	}, nil
+}
+
+// Rejudge 는 조건에 맞는 제출을 찾아 재채점 작업을 시작하고, 바로 작업 상태를 반환한다
+// JUDGE_SUBMIT_WRITE_BACK 이 꺼져 있으면 결과를 submit 테이블에 쓰지 않고 바뀔 결과만 알려 준다
+func (service *RejudgeService) Rejudge(dto GetSubmitsDTO) (RejudgeResponse, error) {
+	if dto.SubmitId == 0 && dto.ProblemId == 0 && len(dto.Results) == 0 && len(dto.Languages) == 0 && dto.From.IsZero() && dto.To.IsZero() {
+		log.Error(ErrEmptyRejudgeFilter)
+		return RejudgeResponse{}, ErrEmptyRejudgeFilter
+	}
+
+	submits, err := service.submitRepository.GetSubmits(dto)
+	if err != nil {
+		log.Error(err)
+		return RejudgeResponse{}, err
+	}
+	if len(submits) == 0 {
+		err = errors.New("no submits to rejudge")
+		log.Error(err)
+		return RejudgeResponse{}, err
+	}
+
+	job := &rejudgeJob{
+		id:      uuid.NewString(),
+		dryRun:  !service.writeBack,
+		status:  RejudgeRunning,
+		total:   len(submits),
+		changes: make([]RejudgeChange, 0),
+		failed:  make([]RejudgeError, 0),
+	}
+
+	service.mutex.Lock()
+	service.cleanJobs()
+	service.jobs[job.id] = job
+	service.mutex.Unlock()
+
+	log.Info("--------------------------------")
+	log.Info("재채점 시작: ", job.id, ", 제출 ", job.total, "개")
+
+	go service.runRejudge(job, submits)
+
+	return job.response(), nil
+}
+
+func (service *RejudgeService) GetRejudge(jobId string) (RejudgeResponse, bool) {
+	service.mutex.Lock()
+	job, exists := service.jobs[jobId]
+	service.mutex.Unlock()
+
+	if !exists {
+		return RejudgeResponse{}, false
+	}
+
+	return job.response(), true
+}
+
+func (service *RejudgeService) runRejudge(job *rejudgeJob, submits []GetSubmitDAO) {
+	for _, submit := range submits {
+		change, err := service.rejudgeSubmit(submit)
+
+		job.mutex.Lock()
+		job.done++
+		switch {
+		case err != nil:
+			job.failed = append(job.failed, RejudgeError{SubmitId: submit.SubmitId, Error: err.Error()})
+		case change.Before != change.After:
+			job.changes = append(job.changes, change)
+		default:
+			job.unchanged++
+		}
+		job.mutex.Unlock()
+	}
+
+	job.mutex.Lock()
+	job.status = RejudgeDone
+	job.finishedAt = time.Now()
+	job.mutex.Unlock()
+
+	log.Info("--------------------------------")
+	log.Info("재채점 완료: ", job.id)
+}
+
+// rejudgeSubmit 은 채점 중 패닉이 나도 작업이 멈추지 않도록 그 제출만 실패로 처리한다
+func (service *RejudgeService) rejudgeSubmit(submit GetSubmitDAO) (change RejudgeChange, err error) {
+	defer recoverJudgeError("submit", submit.SubmitId, &err)
+
+	// 빌드할 수 없는 언어를 채점하면 컴파일 에러가 되어 실제 결과를 덮어쓰게 되므로 건너뛴다
+	if !IsSupported(submit.Language) {
+		return RejudgeChange{}, fmt.Errorf("language %q is not supported on this node", submit.Language)
+	}
+
+	code, err := service.submitRepository.GetCode(submit.SubmitId, submit.Language)
+	if err != nil {
+		log.Error(err)
+		return RejudgeChange{}, err
+	}
+
+	dto := NewSubmitProblemDTO(submit.ProblemId, submit.SubmitId, submit.Language, code)
+	result, usedTime, usedMemory, err := service.problemService.ResubmitProblem(context.Background(), dto)
+	if result == JudgeUnknown {
+		log.Error(err)
+		return RejudgeChange{}, err
+	}
+
+	if service.writeBack {
+		saveSubmitResultDTO := SaveSubmitResultDTO{
+			SubmitId:   submit.SubmitId,
+			Result:     result.String(),
+			UsedMemory: usedMemory,
+			UsedTime:   usedTime,
+		}
+		if err = service.submitRepository.SaveSubmitResult(saveSubmitResultDTO); err != nil {
+			log.Error(err)
+			return RejudgeChange{}, err
+		}
+	}
+
+	return RejudgeChange{
+		SubmitId:   submit.SubmitId,
+		Before:     submit.Result,
+		After:      result.String(),
+		UsedTime:   usedTime,
+		UsedMemory: usedMemory,
+	}, nil
+}
+
+// cleanJobs 는 보관 시간이 지난 작업을 지운다. mutex 를 잡은 채로 호출해야 한다
+func (service *RejudgeService) cleanJobs() {
+	for id, job := range service.jobs {
+		job.mutex.Lock()
+		expired := job.status == RejudgeDone && time.Since(job.finishedAt) > rejudgeJobRetention
+		job.mutex.Unlock()
+
+		if expired {
+			delete(service.jobs, id)
+		}
+	}
+}
+
+func (job *rejudgeJob) response() RejudgeResponse {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	return RejudgeResponse{
+		JobId:     job.id,
+		DryRun:    job.dryRun,
+		Status:    job.status.String(),
+		Total:     job.total,
+		Done:      job.done,
+		Changes:   append([]RejudgeChange{}, job.changes...),
+		Unchanged: job.unchanged,
+		Failed:    append([]RejudgeError{}, job.failed...),
+	}
+}
+
+// submit 테이블은 백엔드가 관리하고 스키마가 확정되지 않았으므로, JUDGE_SUBMIT_WRITE_BACK 을 켠 경우에만 채점 결과를 쓴다
+func isSubmitWriteBack() bool {
+	writeBack, _ := strconv.ParseBool(GetEnv("JUDGE_SUBMIT_WRITE_BACK"))
+	return writeBack
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.opentelemetry.io/otel/attribute"
+	. "leita/src/commands"
+	. "leita/src/entities"
+	"leita/src/tracing"
+	. "leita/src/utils"
+)
+
+const (
+	defaultStressTimeBudget = 10 * time.Second
+	defaultStressMaxBudget  = time.Minute
+	defaultStressTimeLimit  = 2 * time.Second
+	defaultStressMaxSize    = 10
+	// size 를 하나 늘리기 전에 같은 size 로 실행하는 횟수
+	stressRunsPerSize = 10
+	// 틀리는 입력을 찾은 뒤 더 작은 입력을 찾으려고 더 실행하는 횟수
+	stressShrinkRuns = 50
+	// 응답에 담는 입력과 출력의 최대 길이
+	stressPreviewSize = 64 << 10
+)
+
+var ErrInvalidStress = errors.New("invalid stress test")
+
+// StressService 는 생성기로 만든 입력마다 후보 풀이와 완전 탐색 풀이의 출력을 비교한다.
+// 생성기는 작은 size 부터 받으므로 처음 찾은 입력도 작은 편이고, 찾은 뒤에는 그 size 이하로 더 실행해서 가장 짧은 입력을 남긴다
+type StressService struct {
+	maxBudget time.Duration
+}
+
+// 스트레스 테스트에서 빌드한 프로그램
+type stressProgram struct {
+	name   string
+	runCmd []string
+}
+
+func NewStressService() (*StressService, error) {
+	maxBudget, err := parseDurationEnv("JUDGE_STRESS_MAX_BUDGET", defaultStressMaxBudget)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &StressService{
+		maxBudget: maxBudget,
+	}, nil
+}
+
+// StressTest 는 다른 출력을 찾거나 시간 예산을 다 쓸 때까지 실행한다.
+// 예산은 실행을 시작할 때만 확인하므로 마지막 실행만큼 넘길 수 있다
+func (service *StressService) StressTest(ctx context.Context, dto StressTestDTO) (StressTestResponse, error) {
+	budget := defaultStressTimeBudget
+	if dto.TimeBudget > 0 {
+		budget = time.Duration(dto.TimeBudget) * time.Millisecond
+	}
+	if budget > service.maxBudget {
+		return StressTestResponse{}, fmt.Errorf("%w: time budget exceeds %s", ErrInvalidStress, service.maxBudget)
+	}
+
+	timeLimit := defaultStressTimeLimit
+	if dto.TimeLimit > 0 {
+		timeLimit = time.Duration(dto.TimeLimit) * time.Millisecond
+	}
+	timeLimit = min(timeLimit, budget)
+
+	maxSize := defaultStressMaxSize
+	if dto.MaxSize > 0 {
+		maxSize = dto.MaxSize
+	}
+
+	// JAVA 처럼 빌드 결과물을 작업 디렉터리 밖에 두는 언어는 프로그램끼리 덮어쓴다
+	shared := 0
+	for _, source := range []SourceCode{dto.Candidate, dto.Brute, dto.Generator} {
+		if !strings.Contains(strings.Join(Commands[source.Language].RunCmd, " "), "{SUBMIT_ID}") {
+			shared++
+		}
+	}
+	if shared > 1 {
+		return StressTestResponse{}, fmt.Errorf("%w: only one program can use a language that builds outside its workspace", ErrInvalidStress)
+	}
+
+	id := RandomInt(int(math.Pow10(11)), int(math.Pow10(12)-1))
+	workspace := filepath.Join("stress", strconv.Itoa(id))
+	if err := beginJudge(workspace); err != nil {
+		return StressTestResponse{}, err
+	}
+	defer endJudge(workspace)
+	defer os.RemoveAll(workspace)
+
+	ctx, span := tracing.Start(ctx, "StressTest", attribute.Int("judge.submit_id", id))
+	response, err := service.stressTest(ctx, workspace, dto, budget, timeLimit, maxSize)
+	span.SetAttributes(attribute.String("judge.stress", response.Status), attribute.Int("judge.iterations", response.Iterations))
+	tracing.End(span, err)
+	if err != nil {
+		log.WithContext(ctx).Error(err)
+		return StressTestResponse{}, err
+	}
+
+	log.WithContext(ctx).Infow("스트레스 테스트 완료", "status", response.Status, "iterations", response.Iterations, "elapsed", response.Elapsed)
+	return response, nil
+}
+
+func (service *StressService) stressTest(ctx context.Context, workspace string, dto StressTestDTO, budget, timeLimit time.Duration, maxSize int) (StressTestResponse, error) {
+	startTime := time.Now()
+	deadline := startTime.Add(budget)
+
+	response := StressTestResponse{Status: StressPassed.String()}
+	finish := func(status StressStatusEnum, message string) (StressTestResponse, error) {
+		response.Status = status.String()
+		response.Error = message
+		response.Elapsed = time.Since(startTime).Milliseconds()
+		return response, nil
+	}
+
+	// 빌드 명령의 {JUDGE_TYPE}/{SUBMIT_ID} 가 stress/{id}/{번호} 가 된다
+	programs := make([]stressProgram, 0, 3)
+	for i, source := range []struct {
+		name string
+		SourceCode
+	}{{"generator", dto.Generator}, {"brute", dto.Brute}, {"candidate", dto.Candidate}} {
+		command := Commands[source.Language]
+		buildCmd := ReplaceCommand(command.BuildCmd, workspace, i)
+		runCmd := ReplaceCommand(command.RunCmd, workspace, i)
+
+		if result, err := buildSource(ctx, i, source.Language, workspace, source.Code, buildCmd); result != JudgeCorrect {
+			if errors.Is(err, ErrDraining) {
+				return StressTestResponse{}, err
+			}
+			return finish(StressFailed, fmt.Sprintf("%s: %s: %s", source.name, result, ErrStrIfNotNil(err)))
+		}
+		programs = append(programs, stressProgram{name: source.name, runCmd: runCmd})
+	}
+	generator, brute, candidate := programs[0], programs[1], programs[2]
+
+	inputFilePath := filepath.Join(workspace, "input")
+	expectedFilePath := filepath.Join(workspace, "expected")
+	outputFilePath := filepath.Join(workspace, "output")
+	limit := int(timeLimit.Milliseconds())
+
+	shrinkLeft := 0
+	for time.Now().Before(deadline) {
+		if response.Counterexample != nil && shrinkLeft == 0 {
+			break
+		}
+
+		size := min(maxSize, 1+response.Iterations/stressRunsPerSize)
+		if response.Counterexample != nil {
+			size = RandomInt(1, response.Counterexample.Size+1)
+			shrinkLeft--
+		}
+		seed := RandomInt(1, math.MaxInt32)
+		response.Iterations++
+
+		cmd := append(append([]string{}, generator.runCmd...), strconv.Itoa(seed), strconv.Itoa(size))
+		result, _, _, stderr, err := executeProgramWithStderr(ctx, cmd, os.DevNull, inputFilePath, limit, 0)
+		if result == JudgeUnknown {
+			return StressTestResponse{}, err
+		}
+		if result != JudgeCorrect {
+			return finish(StressFailed, stressFailure(generator, result, stderr, seed, size))
+		}
+
+		result, _, _, stderr, err = executeProgramWithStderr(ctx, brute.runCmd, inputFilePath, expectedFilePath, limit, 0)
+		if result == JudgeUnknown {
+			return StressTestResponse{}, err
+		}
+		if result != JudgeCorrect {
+			// 찾은 입력이 있으면 더 작은 입력을 찾다가 완전 탐색 풀이가 실패한 것이므로 찾은 입력을 돌려준다
+			if response.Counterexample != nil {
+				break
+			}
+			return finish(StressFailed, stressFailure(brute, result, stderr, seed, size))
+		}
+
+		result, _, _, stderr, err = executeProgramWithStderr(ctx, candidate.runCmd, inputFilePath, outputFilePath, limit, 0)
+		if result == JudgeUnknown {
+			return StressTestResponse{}, err
+		}
+		if result == JudgeCorrect {
+			equal, err := checkDifference(ctx, CheckerExact, outputFilePath, expectedFilePath)
+			if err != nil {
+				return StressTestResponse{}, err
+			}
+			if equal {
+				continue
+			}
+			result = JudgeWrong
+		}
+
+		counterexample, err := newStressCounterexample(seed, size, result, stderr, inputFilePath, expectedFilePath, outputFilePath)
+		if err != nil {
+			return StressTestResponse{}, err
+		}
+		if response.Counterexample == nil {
+			shrinkLeft = stressShrinkRuns
+		}
+		if response.Counterexample == nil || len(counterexample.Input) < len(response.Counterexample.Input) {
+			response.Counterexample = &counterexample
+		}
+	}
+
+	if response.Counterexample != nil {
+		return finish(StressFound, "")
+	}
+
+	return finish(StressPassed, "")
+}
+
+func stressFailure(program stressProgram, result JudgeResultEnum, stderr string, seed, size int) string {
+	message := fmt.Sprintf("%s: %s (seed %d, size %d)", program.name, result, seed, size)
+	if stderr = strings.TrimSpace(stderr); stderr != "" {
+		message += ": " + stderr
+	}
+
+	return message
+}
+
+func newStressCounterexample(seed, size int, result JudgeResultEnum, stderr, inputFilePath, expectedFilePath, outputFilePath string) (StressCounterexample, error) {
+	input, err := ReadFilePreview(inputFilePath, stressPreviewSize)
+	if err != nil {
+		return StressCounterexample{}, err
+	}
+
+	expected, err := ReadFilePreview(expectedFilePath, stressPreviewSize)
+	if err != nil {
+		return StressCounterexample{}, err
+	}
+
+	output, err := ReadFilePreview(outputFilePath, stressPreviewSize)
+	if err != nil {
+		return StressCounterexample{}, err
+	}
+
+	return StressCounterexample{
+		Seed:     seed,
+		Size:     size,
+		Input:    string(input),
+		Expected: string(expected),
+		Output:   string(output),
+		Result:   result.String(),
+		Error:    strings.TrimSpace(stderr),
+	}, nil
+}
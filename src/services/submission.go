@@ -0,0 +1,233 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	. "leita/src/entities"
+	"leita/src/repositories"
+	. "leita/src/utils"
+)
+
+// 끝난 제출의 결과를 같은 submitId 로 다시 요청할 때 돌려줄 수 있도록 남겨두는 시간
+const defaultSubmissionRetention = time.Hour
+
+var ErrSubmissionConflict = errors.New("submission already exists with different code")
+
+// 같은 submitId 를 같은 작업 디렉터리에서 동시에 채점하지 않도록 제출을 submitId 로 추적한다.
+// 백엔드가 시간 초과 뒤에 같은 제출을 다시 보내면, 채점 중이면 그 채점의 결과를 기다리고 끝났으면 남겨둔 결과를 돌려준다.
+// 코드 digest 와 결과는 judge_submission 테이블에도 남겨서, 다른 노드나 워커가 받았거나 재시작 전에 받은 제출과도 비교한다.
+// 다른 노드에서 채점 중인 제출은 기다리지 않고 한 번 더 채점한다
+var (
+	submissionsOnce sync.Once
+	submissions     *submissionTracker
+	submissionsErr  error
+)
+
+type submissionTracker struct {
+	repository  *repositories.SubmissionRepository
+	mutex       sync.Mutex
+	submissions map[int]*trackedSubmission
+	retention   time.Duration
+}
+
+type trackedSubmission struct {
+	digest []byte
+	done   chan struct{}
+
+	result     JudgeResultEnum
+	usedTime   int64
+	usedMemory int64
+	err        error
+	finishedAt time.Time
+}
+
+func getSubmissionTracker() (*submissionTracker, error) {
+	submissionsOnce.Do(func() {
+		submissions, submissionsErr = newSubmissionTracker()
+	})
+
+	return submissions, submissionsErr
+}
+
+func newSubmissionTracker() (*submissionTracker, error) {
+	repository, err := repositories.NewSubmissionRepository()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	retention, err := parseDurationEnv("JUDGE_SUBMISSION_RETENTION", defaultSubmissionRetention)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &submissionTracker{
+		repository:  repository,
+		submissions: make(map[int]*trackedSubmission),
+		retention:   retention,
+	}, nil
+}
+
+// 문제, 언어, 코드가 모두 같아야 같은 제출로 본다
+func submissionDigest(dto SubmitProblemDTO) []byte {
+	hash := sha256.New()
+	hash.Write([]byte(strconv.Itoa(dto.ProblemId) + "\n" + dto.Language + "\n"))
+	hash.Write(dto.Code)
+	return hash.Sum(nil)
+}
+
+// acquire 는 새 제출이면 owner 로 등록한다. owner 가 아니면 반환된 제출이 끝나기를 기다려 그 결과를 쓴다.
+// force 면 코드가 달라도 진행 중인 채점이 끝나기를 기다린 뒤 새로 채점한다
+func (tracker *submissionTracker) acquire(dto SubmitProblemDTO, force bool) (*trackedSubmission, bool, error) {
+	digest := submissionDigest(dto)
+
+	for {
+		tracker.mutex.Lock()
+		tracker.clean()
+
+		submission, exists := tracker.submissions[dto.SubmitId]
+		if !exists || (force && submission.finished()) {
+			submission = &trackedSubmission{
+				digest: digest,
+				done:   make(chan struct{}),
+			}
+			tracker.submissions[dto.SubmitId] = submission
+			tracker.mutex.Unlock()
+			return tracker.claim(dto.SubmitId, submission, force)
+		}
+		tracker.mutex.Unlock()
+
+		if force {
+			<-submission.done
+			continue
+		}
+
+		if !bytes.Equal(submission.digest, digest) {
+			return nil, false, ErrSubmissionConflict
+		}
+
+		return submission, false, nil
+	}
+}
+
+// claim 은 owner 로 등록한 제출을 judge_submission 에 남긴다.
+// 다른 코드로 받은 적이 있으면 ErrSubmissionConflict 를, 보관 시간 안에 끝난 결과가 있으면 채점하지 않고 그 결과를 돌려준다
+func (tracker *submissionTracker) claim(submitId int, submission *trackedSubmission, force bool) (*trackedSubmission, bool, error) {
+	digest := hex.EncodeToString(submission.digest)
+
+	stored, err := tracker.repository.ClaimSubmission(submitId, digest, force)
+	if err == nil && stored.CodeDigest != digest {
+		err = ErrSubmissionConflict
+	}
+	if err != nil {
+		tracker.finish(submitId, submission, JudgeUnknown, 0, 0, err)
+		return nil, false, err
+	}
+
+	result := ParseJudgeResult(stored.Result)
+	if stored.Finished && result != JudgeUnknown && time.Since(stored.FinishedAt) <= tracker.retention {
+		var storedErr error
+		if stored.Error != "" {
+			storedErr = errors.New(stored.Error)
+		}
+		tracker.finish(submitId, submission, result, stored.UsedTime, stored.UsedMemory, storedErr)
+		return submission, false, nil
+	}
+
+	return submission, true, nil
+}
+
+// check 는 채점을 시작하지 않고 같은 submitId 에 다른 코드가 있는지만 확인한다
+func (tracker *submissionTracker) check(dto SubmitProblemDTO) error {
+	digest := submissionDigest(dto)
+
+	tracker.mutex.Lock()
+	submission, exists := tracker.submissions[dto.SubmitId]
+	tracker.mutex.Unlock()
+	if exists && !bytes.Equal(submission.digest, digest) {
+		return ErrSubmissionConflict
+	}
+
+	stored, err := tracker.repository.GetSubmission(dto.SubmitId)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if stored != nil && stored.CodeDigest != hex.EncodeToString(digest) {
+		return ErrSubmissionConflict
+	}
+
+	return nil
+}
+
+// save 는 owner 가 채점한 결과를 judge_submission 에 남긴다. UNKNOWN 은 finish 처럼 남기지 않는다
+func (tracker *submissionTracker) save(submitId int, submission *trackedSubmission, result JudgeResultEnum, usedTime, usedMemory int64, err error) {
+	if result == JudgeUnknown {
+		return
+	}
+
+	saveSubmissionResultDTO := SaveSubmissionResultDTO{
+		SubmitId:   submitId,
+		CodeDigest: hex.EncodeToString(submission.digest),
+		Result:     result.String(),
+		UsedTime:   usedTime,
+		UsedMemory: usedMemory,
+		Error:      ErrStrIfNotNil(err),
+	}
+	if err := tracker.repository.SaveSubmissionResult(saveSubmissionResultDTO); err != nil {
+		log.Error(err)
+	}
+}
+
+// finish 는 결과를 남기고 기다리는 요청을 깨운다.
+// UNKNOWN 은 채점 서버의 문제일 수 있으므로 남기지 않아서, 다시 요청하면 새로 채점한다
+func (tracker *submissionTracker) finish(submitId int, submission *trackedSubmission, result JudgeResultEnum, usedTime, usedMemory int64, err error) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	submission.result = result
+	submission.usedTime = usedTime
+	submission.usedMemory = usedMemory
+	submission.err = err
+	submission.finishedAt = time.Now()
+	close(submission.done)
+
+	if result == JudgeUnknown && tracker.submissions[submitId] == submission {
+		delete(tracker.submissions, submitId)
+	}
+}
+
+// clean 은 보관 시간이 지난 제출을 지운다. mutex 를 잡은 채로 호출해야 한다
+func (tracker *submissionTracker) clean() {
+	for submitId, submission := range tracker.submissions {
+		if submission.finished() && time.Since(submission.finishedAt) > tracker.retention {
+			delete(tracker.submissions, submitId)
+		}
+	}
+}
+
+func (submission *trackedSubmission) finished() bool {
+	select {
+	case <-submission.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (submission *trackedSubmission) event() JudgeEvent {
+	return JudgeEvent{
+		Result:     submission.result.String(),
+		Error:      ErrStrIfNotNil(submission.err),
+		UsedTime:   submission.usedTime,
+		UsedMemory: submission.usedMemory,
+	}
+}
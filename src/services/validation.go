@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.opentelemetry.io/otel/attribute"
+	. "leita/src/entities"
+	"leita/src/tracing"
+	. "leita/src/utils"
+)
+
+// 검증기가 테스트 하나를 읽는 데 쓸 수 있는 시간
+const defaultValidatorTimeLimit = 10 * time.Second
+
+// 같은 문제를 동시에 검증하면 작업 디렉터리가 겹치므로 문제마다 하나씩만 검증한다
+var validationLocks sync.Map
+
+// ValidationService 는 문제의 검증기로 모든 테스트 입력을 검사한다.
+// 검증기는 testlib 검증기처럼 표준 입력으로 테스트를 읽고, 올바르지 않으면 0 이 아닌 값으로 끝나며 이유를 표준 에러에 쓴다.
+// 거절된 테스트가 있거나(INVALID) 검증하지 못했거나(ERROR) 검증한 뒤에 테스트 케이스가 바뀐 문제는
+// 다시 검증해서 통과하기 전까지 채점하지 않는다
+type ValidationService struct {
+	problemService *ProblemService
+	timeLimit      time.Duration
+}
+
+func NewValidationService() (*ValidationService, error) {
+	problemService, err := NewProblemService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	timeLimit, err := parseDurationEnv("JUDGE_VALIDATOR_TIME_LIMIT", defaultValidatorTimeLimit)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &ValidationService{
+		problemService: problemService,
+		timeLimit:      timeLimit,
+	}, nil
+}
+
+// SetValidator 는 검증기를 문제에 등록하고 바로 검증한다
+func (service *ValidationService) SetValidator(ctx context.Context, problemId int, language string, code []byte) (ValidationResponse, error) {
+	repository := service.problemService.repository
+
+	manifest, err := repository.GetManifest(problemId)
+	if err != nil {
+		log.Error(err)
+		return ValidationResponse{}, err
+	}
+	if manifest == nil {
+		manifest = &ProblemManifest{ProblemId: problemId}
+	}
+
+	validator := PackageFile{
+		Path:     ProblemObjectPath(problemId, filepath.Join("validator", "Main."+FileExtension(language))),
+		Language: language,
+	}
+	if err = repository.SaveCode(validator.Path, EncodeBase64(code)); err != nil {
+		log.Error(err)
+		return ValidationResponse{}, err
+	}
+
+	manifest.Validators = []PackageFile{validator}
+	if err = repository.SaveManifest(*manifest); err != nil {
+		log.Error(err)
+		return ValidationResponse{}, err
+	}
+
+	return service.Validate(ctx, problemId)
+}
+
+// GetValidation 은 마지막 검증 결과를 반환한다
+func (service *ValidationService) GetValidation(problemId int) (ValidationResponse, error) {
+	response, err := service.problemService.repository.GetValidation(problemId)
+	if err != nil {
+		log.Error(err)
+		return ValidationResponse{}, err
+	}
+	if response == nil {
+		return ValidationResponse{ProblemId: problemId, Status: ValidationNone.String()}, nil
+	}
+
+	return *response, nil
+}
+
+// Validate 는 문제의 첫 번째 검증기로 모든 테스트 입력을 검사하고 결과를 남긴다. 테스트 번호는 1 부터 센다.
+// 검증기가 없으면 남아 있던 결과를 지운다
+func (service *ValidationService) Validate(ctx context.Context, problemId int) (ValidationResponse, error) {
+	lock, _ := validationLocks.LoadOrStore(problemId, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	ctx, span := tracing.Start(ctx, "Validate", attribute.Int("judge.problem_id", problemId))
+	response, err := service.validate(ctx, problemId)
+	span.SetAttributes(attribute.String("judge.validation", response.Status))
+	tracing.End(span, err)
+	if err != nil {
+		log.WithContext(ctx).Error(err)
+		return ValidationResponse{}, err
+	}
+
+	if err = service.saveValidation(response); err != nil {
+		log.WithContext(ctx).Error(err)
+		return ValidationResponse{}, err
+	}
+
+	log.WithContext(ctx).Infow("테스트 데이터 검증 완료", "problemId", problemId, "status", response.Status, "tests", len(response.Tests))
+	return response, nil
+}
+
+// 검증기가 없으면 남아 있던 결과를 지운다
+func (service *ValidationService) saveValidation(response ValidationResponse) error {
+	repository := service.problemService.repository
+	if response.Status == ValidationNone.String() {
+		return repository.DeleteValidation(response.ProblemId)
+	}
+	return repository.SaveValidation(response)
+}
+
+// checkTestData 는 검증기가 거절했거나, 검증하지 못했거나, 검증한 뒤에 바뀐 테스트 데이터로 채점하지 않도록 한다.
+// 검증기가 없는 문제(NONE)는 그대로 채점한다
+func checkTestData(problemInfo GetProblemInfoDAO) error {
+	if problemInfo.ValidationStatus == ValidationNone.String() {
+		return nil
+	}
+	if problemInfo.ValidationStatus != ValidationValid.String() {
+		return fmt.Errorf("%w: validation status is %s", ErrInvalidTestData, problemInfo.ValidationStatus)
+	}
+	if problemInfo.ValidationVersion != problemInfo.TestcasesVersion {
+		return fmt.Errorf("%w: test data changed after validation", ErrInvalidTestData)
+	}
+	return nil
+}
+
+// 검증기를 빌드하거나 실행하지 못한 것은 ERROR 결과로 남기고, 저장소 에러만 err 로 반환한다
+func (service *ValidationService) validate(ctx context.Context, problemId int) (ValidationResponse, error) {
+	repository := service.problemService.repository
+
+	response := ValidationResponse{
+		ProblemId:   problemId,
+		Status:      ValidationNone.String(),
+		Tests:       make([]TestValidation, 0),
+		ValidatedAt: time.Now(),
+	}
+
+	manifest, err := repository.GetManifest(problemId)
+	if err != nil {
+		return ValidationResponse{}, err
+	}
+	if manifest == nil || len(manifest.Validators) == 0 {
+		return response, nil
+	}
+
+	validator := manifest.Validators[0]
+	response.Validator = &validator
+
+	// 검증하는 동안 테스트 케이스가 바뀌면 남긴 버전이 지금 버전과 달라져서 채점하지 않게 된다
+	version, err := repository.GetTestcasesVersion(problemId)
+	if err != nil {
+		return ValidationResponse{}, err
+	}
+	response.DataVersion = version
+
+	workspace := filepath.Join("validate", strconv.Itoa(problemId))
+	if err = beginJudge(workspace); err != nil {
+		return ValidationResponse{}, err
+	}
+	defer endJudge(workspace)
+	defer os.RemoveAll(workspace)
+
+	if err = MakeDir(workspace); err != nil {
+		return ValidationResponse{}, err
+	}
+
+	if err = writeProblemResources(repository, manifest, workspace); err != nil {
+		return ValidationResponse{}, err
+	}
+
+	runCmd, message, err := buildProblemProgram(ctx, repository, "validate", problemId, validator)
+	if err != nil {
+		return ValidationResponse{}, err
+	}
+	if message != "" {
+		response.Status = ValidationError.String()
+		response.Error = message
+		return response, nil
+	}
+
+	cacheDir, release, err := service.problemService.testCaseCache.Acquire(problemId, version, func(dir string) error {
+		return fetchTestCases(ctx, service.problemService, problemId, dir)
+	})
+	if err != nil {
+		return ValidationResponse{}, err
+	}
+	defer release()
+
+	testCaseNum, err := GetTestCaseNum(filepath.Join(cacheDir, "in"))
+	if err != nil {
+		return ValidationResponse{}, err
+	}
+
+	inputFilePaths := make([]string, 0, testCaseNum)
+	for i := 0; i < testCaseNum; i++ {
+		inputFilePaths = append(inputFilePaths, filepath.Join(cacheDir, "in", strconv.Itoa(i)+".in"))
+	}
+	if err = service.validateInputs(ctx, runCmd, workspace, inputFilePaths, &response); err != nil {
+		return ValidationResponse{}, err
+	}
+
+	return response, nil
+}
+
+// validateInputs 는 빌드한 검증기로 inputFilePaths 를 차례로 검사해서 response 의 상태와 테스트별 결과를 채운다.
+// inputFilePaths[i] 는 i+1 번 테스트의 입력이다
+func (service *ValidationService) validateInputs(ctx context.Context, runCmd []string, workspace string, inputFilePaths []string, response *ValidationResponse) error {
+	response.Status = ValidationValid.String()
+	for i, inputFilePath := range inputFilePaths {
+		executeFilePath := filepath.Join(workspace, strconv.Itoa(i)+".validate")
+
+		result, _, _, stderr, err := executeProgramWithStderr(ctx, runCmd, inputFilePath, executeFilePath, int(service.timeLimit.Milliseconds()), 0)
+		if errors.Is(err, ErrDraining) {
+			return err
+		}
+
+		testValidation := TestValidation{Test: i + 1, Valid: result == JudgeCorrect}
+		if !testValidation.Valid {
+			response.Status = ValidationInvalid.String()
+			testValidation.Error = strings.TrimSpace(stderr)
+			if testValidation.Error == "" {
+				testValidation.Error = result.String() + ": " + ErrStrIfNotNil(err)
+			}
+		}
+		response.Tests = append(response.Tests, testValidation)
+	}
+
+	return nil
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	. "leita/src/commands"
+	. "leita/src/entities"
+	. "leita/src/utils"
+)
+
+const (
+	// 끝난 검증 작업을 조회할 수 있도록 남겨두는 시간
+	verificationJobRetention = time.Hour
+	// 가장 느린 정답 풀이가 시간 제한의 이 비율을 넘으면 시간 제한이 빡빡하다고 경고한다
+	verificationTimeLimitMargin = 0.5
+)
+
+// Polygon 풀이 태그마다 기대하는 결과. 채점 서버는 presentation error 를 따로 구분하지 않으므로 presentation-error 는 WRONG 이다
+var solutionTagResults = map[string][]JudgeResultEnum{
+	MainSolutionTag:                   {JudgeCorrect},
+	"accepted":                        {JudgeCorrect},
+	"wrong-answer":                    {JudgeWrong},
+	"presentation-error":              {JudgeWrong},
+	"time-limit-exceeded":             {JudgeTimeOut},
+	"time-limit-exceeded-or-accepted": {JudgeTimeOut, JudgeCorrect},
+	"time-limit-exceeded-or-memory-limit-exceeded": {JudgeTimeOut, JudgeMemoryOut},
+	"memory-limit-exceeded":                        {JudgeMemoryOut},
+	"rejected":                                     {JudgeWrong, JudgeRuntimeError, JudgeMemoryOut, JudgeTimeOut},
+}
+
+var ErrInvalidSolution = errors.New("invalid solution")
+
+// VerificationService 는 문제에 붙은 풀이를 모두 일반 제출처럼 채점해서 태그와 결과가 다른 풀이를 찾고,
+// 가장 느린 정답 풀이가 시간 제한에 얼마나 가까운지 보고한다
+type VerificationService struct {
+	problemService *ProblemService
+
+	mutex sync.Mutex
+	jobs  map[string]*verificationJob
+}
+
+type verificationJob struct {
+	mutex      sync.Mutex
+	id         string
+	problemId  int
+	status     VerificationStatusEnum
+	timeLimit  int
+	solutions  []SolutionVerification
+	done       int
+	err        string
+	finishedAt time.Time
+}
+
+func NewVerificationService() (*VerificationService, error) {
+	problemService, err := NewProblemService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return &VerificationService{
+		problemService: problemService,
+		jobs:           make(map[string]*verificationJob),
+	}, nil
+}
+
+// AddSolution 은 같은 이름의 풀이가 있으면 바꾸고, 없으면 문제에 풀이를 붙인다
+func (service *VerificationService) AddSolution(dto AddSolutionDTO) error {
+	if !generatorNamePattern.MatchString(dto.Name) {
+		return fmt.Errorf("%w: solution name %q", ErrInvalidSolution, dto.Name)
+	}
+	if _, exists := solutionTagResults[dto.Tag]; !exists {
+		return fmt.Errorf("%w: unknown tag %q", ErrInvalidSolution, dto.Tag)
+	}
+
+	repository := service.problemService.repository
+
+	manifest, err := repository.GetManifest(dto.ProblemId)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if manifest == nil {
+		manifest = &ProblemManifest{ProblemId: dto.ProblemId}
+	}
+
+	solution := PackageSolution{
+		PackageFile: PackageFile{
+			Path:     ProblemObjectPath(dto.ProblemId, filepath.Join("solutions", dto.Name+"."+FileExtension(dto.Language))),
+			Language: dto.Language,
+			Name:     dto.Name,
+		},
+		Tag: dto.Tag,
+	}
+	if err = repository.SaveCode(solution.Path, EncodeBase64(dto.Code)); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	// main 풀이는 하나만 둔다
+	solutions := []PackageSolution{solution}
+	for _, existing := range manifest.Solutions {
+		if existing.Name == dto.Name || (dto.Tag == MainSolutionTag && existing.Tag == MainSolutionTag) {
+			continue
+		}
+		solutions = append(solutions, existing)
+	}
+	manifest.Solutions = solutions
+
+	if err = repository.SaveManifest(*manifest); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// Verify 는 문제의 풀이를 모두 채점하는 작업을 시작하고, 바로 작업 상태를 반환한다
+func (service *VerificationService) Verify(problemId int) (VerificationResponse, error) {
+	repository := service.problemService.repository
+
+	manifest, err := repository.GetManifest(problemId)
+	if err != nil {
+		log.Error(err)
+		return VerificationResponse{}, err
+	}
+	if manifest == nil || len(manifest.Solutions) == 0 {
+		err = errors.New("no solutions to verify")
+		log.Error(err)
+		return VerificationResponse{}, err
+	}
+
+	problemInfo, err := repository.GetProblemInfo(problemId)
+	if err != nil {
+		log.Error(err)
+		return VerificationResponse{}, err
+	}
+
+	job := &verificationJob{
+		id:        uuid.NewString(),
+		problemId: problemId,
+		status:    VerificationRunning,
+		timeLimit: problemInfo.TimeLimit,
+		solutions: make([]SolutionVerification, 0, len(manifest.Solutions)),
+	}
+	for _, solution := range manifest.Solutions {
+		name := solution.Name
+		if name == "" {
+			name = filepath.Base(solution.Path)
+		}
+
+		expected := make([]string, 0)
+		for _, result := range solutionTagResults[solution.Tag] {
+			expected = append(expected, result.String())
+		}
+
+		job.solutions = append(job.solutions, SolutionVerification{
+			Name:      name,
+			Path:      solution.Path,
+			Language:  solution.Language,
+			Tag:       solution.Tag,
+			Expected:  expected,
+			TimeLimit: ApplyLimitMultiplier(problemInfo.TimeLimit, TimeLimitMultiplier(solution.Language)),
+		})
+	}
+
+	service.mutex.Lock()
+	service.cleanJobs()
+	service.jobs[job.id] = job
+	service.mutex.Unlock()
+
+	log.Infow("문제 검증 시작", "jobId", job.id, "problemId", problemId, "solutions", len(job.solutions))
+
+	go service.runVerification(job)
+
+	return job.response(), nil
+}
+
+func (service *VerificationService) GetVerification(jobId string) (VerificationResponse, bool) {
+	service.mutex.Lock()
+	job, exists := service.jobs[jobId]
+	service.mutex.Unlock()
+
+	if !exists {
+		return VerificationResponse{}, false
+	}
+
+	return job.response(), true
+}
+
+// 풀이마다 시간을 재야 하므로 한 번에 풀이 하나만 채점한다
+func (service *VerificationService) runVerification(job *verificationJob) {
+	for i := range job.solutions {
+		job.mutex.Lock()
+		solution := job.solutions[i]
+		job.mutex.Unlock()
+
+		solution = service.verifySolution(job.problemId, solution)
+
+		job.mutex.Lock()
+		job.solutions[i] = solution
+		job.done++
+		job.mutex.Unlock()
+
+		// 서버가 종료 중이면 남은 풀이는 채점하지 않는다
+		if IsDraining() {
+			job.mutex.Lock()
+			job.err = ErrDraining.Error()
+			job.mutex.Unlock()
+			break
+		}
+	}
+
+	job.mutex.Lock()
+	job.status = VerificationDone
+	job.finishedAt = time.Now()
+	job.mutex.Unlock()
+
+	log.Infow("문제 검증 완료", "jobId", job.id, "problemId", job.problemId)
+}
+
+func (service *VerificationService) verifySolution(problemId int, solution SolutionVerification) SolutionVerification {
+	if len(solution.Expected) == 0 {
+		solution.Skipped = true
+		solution.Error = fmt.Sprintf("tag %q has no expected verdict", solution.Tag)
+		return solution
+	}
+	if !IsSupported(solution.Language) {
+		solution.Skipped = true
+		solution.Error = fmt.Sprintf("language %q is not supported on this node", solution.Language)
+		return solution
+	}
+
+	code, err := service.problemService.repository.GetObject(solution.Path)
+	if err != nil {
+		log.Error(err)
+		solution.Error = err.Error()
+		return solution
+	}
+
+	// 실제 제출과 겹치지 않도록 실행처럼 큰 submitId 를 쓴다
+	submitId := RandomInt(int(math.Pow10(11)), int(math.Pow10(12)-1))
+	dto := NewSubmitProblemDTO(problemId, submitId, solution.Language, code)
+	dto.SkipSaveCode = true
+	// 임의의 submitId 로 만든 작업 디렉터리는 다시 쓰지 않으므로 지운다
+	defer os.RemoveAll(filepath.Join("submit", strconv.Itoa(submitId)))
+
+	result, usedTime, usedMemory, err := service.judge(dto)
+	solution.Result = result.String()
+	solution.UsedTime = usedTime
+	solution.UsedMemory = usedMemory
+	if result == JudgeUnknown {
+		solution.Error = ErrStrIfNotNil(err)
+		return solution
+	}
+
+	for _, expected := range solution.Expected {
+		if expected == solution.Result {
+			solution.Matches = true
+		}
+	}
+
+	return solution
+}
+
+// judge 는 채점 중 패닉이 나도 검증 작업이 멈추지 않도록 복구해, 그 풀이를 채점하지 못한 것으로 남기게 한다
+func (service *VerificationService) judge(dto SubmitProblemDTO) (result JudgeResultEnum, usedTime int64, usedMemory int64, err error) {
+	defer recoverJudgeError("submit", dto.SubmitId, &err)
+
+	return service.problemService.submitProblemOnce(context.Background(), dto)
+}
+
+// cleanJobs 는 보관 시간이 지난 작업을 지운다. mutex 를 잡은 채로 호출해야 한다
+func (service *VerificationService) cleanJobs() {
+	for id, job := range service.jobs {
+		job.mutex.Lock()
+		expired := job.status == VerificationDone && time.Since(job.finishedAt) > verificationJobRetention
+		job.mutex.Unlock()
+
+		if expired {
+			delete(service.jobs, id)
+		}
+	}
+}
+
+// 채점하지 못한 풀이(UNKNOWN)도 태그와 다른 것으로 본다. 건너뛴 풀이는 빼고 센다
+func (job *verificationJob) response() VerificationResponse {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	response := VerificationResponse{
+		JobId:      job.id,
+		ProblemId:  job.problemId,
+		Status:     job.status.String(),
+		Total:      len(job.solutions),
+		Done:       job.done,
+		TimeLimit:  job.timeLimit,
+		Solutions:  append([]SolutionVerification{}, job.solutions...),
+		Mismatches: make([]SolutionVerification, 0),
+		Warnings:   make([]string, 0),
+		Error:      job.err,
+	}
+
+	for i, solution := range job.solutions {
+		if i >= job.done || solution.Skipped {
+			continue
+		}
+		if !solution.Matches {
+			response.Mismatches = append(response.Mismatches, solution)
+			continue
+		}
+
+		if solution.Result == JudgeCorrect.String() && (response.SlowestCorrect == nil || solution.UsedTime > response.SlowestCorrect.UsedTime) {
+			slowest := solution
+			response.SlowestCorrect = &slowest
+		}
+	}
+
+	if slowest := response.SlowestCorrect; slowest != nil && slowest.TimeLimit > 0 {
+		if ratio := float64(slowest.UsedTime) / float64(slowest.TimeLimit); ratio > verificationTimeLimitMargin {
+			response.Warnings = append(response.Warnings, fmt.Sprintf("slowest correct solution %s uses %.0f%% of the time limit (%dms / %dms)", slowest.Name, ratio*100, slowest.UsedTime, slowest.TimeLimit))
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	. "leita/src/commands"
+	. "leita/src/entities"
+	"leita/src/repositories"
+	. "leita/src/utils"
+)
+
+const (
+	defaultQueueLease        = 2 * time.Minute
+	defaultQueuePollInterval = time.Second
+	defaultQueueMaxAttempts  = 3
+)
+
+// 종료할 때 워커가 잡고 있는 작업을 끝내거나 돌려놓을 때까지 기다린다
+var workerGroup sync.WaitGroup
+
+// WorkerService 는 JUDGE_MODE=worker 일 때 공유 큐에서 이 서버가 지원하는 언어의 작업을 가져와 채점한다.
+// 작업을 임대하는 동안 lease/3 마다 임대를 연장하고, 워커가 죽어서 임대가 끝난 작업은 다른 워커가 다시 큐에 넣는다
+type WorkerService struct {
+	queue            repositories.JudgeQueue
+	problemService   *ProblemService
+	callbackService  *CallbackService
+	submitRepository *repositories.SubmitRepository
+
+	id           string
+	workers      int
+	lease        time.Duration
+	pollInterval time.Duration
+	maxAttempts  int
+}
+
+func NewWorkerService() (*WorkerService, error) {
+	queue, err := repositories.NewJudgeQueue()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	problemService, err := NewProblemService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	callbackService, err := NewCallbackService()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	submitRepository, err := repositories.NewSubmitRepository()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	workers, err := parseIntEnv("JUDGE_QUEUE_WORKERS", 1)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	maxAttempts, err := parseIntEnv("JUDGE_QUEUE_MAX_ATTEMPTS", defaultQueueMaxAttempts)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	lease, err := parseDurationEnv("JUDGE_QUEUE_LEASE", defaultQueueLease)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	pollInterval, err := parseDurationEnv("JUDGE_QUEUE_POLL_INTERVAL", defaultQueuePollInterval)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+
+	return &WorkerService{
+		queue:            queue,
+		problemService:   problemService,
+		callbackService:  callbackService,
+		submitRepository: submitRepository,
+		id:               hostname + "-" + uuid.NewString()[:8],
+		workers:          workers,
+		lease:            lease,
+		pollInterval:     pollInterval,
+		maxAttempts:      maxAttempts,
+	}, nil
+}
+
+func parseIntEnv(key string, defaultValue int) (int, error) {
+	value := GetEnv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		return 0, fmt.Errorf("invalid %s: %s", key, value)
+	}
+
+	return parsed, nil
+}
+
+// Start 는 JUDGE_QUEUE_WORKERS 개의 워커와 임대가 끝난 작업을 다시 넣는 작업을 시작한다
+func (service *WorkerService) Start() {
+	log.Infow("채점 워커 시작", "workerId", service.id, "workers", service.workers, "languages", Languages())
+
+	for i := 0; i < service.workers; i++ {
+		workerGroup.Add(1)
+		go func() {
+			defer workerGroup.Done()
+			service.work(service.id + "-" + strconv.Itoa(i))
+		}()
+	}
+
+	go service.requeueExpired()
+}
+
+// WaitWorkers 는 Drain 뒤에 워커가 마지막 작업의 결과를 큐에 남길 때까지 ctx 가 끝날 때까지 기다린다
+func WaitWorkers(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		workerGroup.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (service *WorkerService) work(workerId string) {
+	for !IsDraining() {
+		job, err := service.queue.Lease(workerId, Languages(), service.lease)
+		if err != nil {
+			log.Error(err)
+		}
+		if job == nil {
+			time.Sleep(service.pollInterval)
+			continue
+		}
+
+		service.runJob(workerId, job)
+	}
+}
+
+// runJob 은 작업을 끝낸 것으로 큐에 남긴 뒤에만 결과를 쓰고 콜백을 보낸다.
+// 임대를 잃은 작업은 다른 워커가 다시 채점하므로 결과를 버린다
+func (service *WorkerService) runJob(workerId string, job *JudgeJob) {
+	log.Infow("채점 작업 시작", "jobId", job.Id, "submitId", job.SubmitId, "attempts", job.Attempts)
+
+	stopHeartbeat := service.heartbeat(workerId, job.Id)
+	dto := NewSubmitProblemDTO(job.ProblemId, job.SubmitId, job.Language, job.Code)
+	result, usedTime, usedMemory, err := service.judge(dto)
+	leaseLost := stopHeartbeat()
+
+	if leaseLost {
+		log.Warnw("임대를 잃은 채점 작업의 결과를 버립니다", "jobId", job.Id, "submitId", job.SubmitId)
+		return
+	}
+
+	// 종료 중이라 채점하지 못한 작업은 다른 워커가 바로 가져가도록 돌려놓는다
+	if errors.Is(err, ErrDraining) {
+		if err = service.queue.Release(job.Id, workerId); err != nil {
+			log.Error(err)
+		}
+		return
+	}
+
+	completeJudgeJobDTO := CompleteJudgeJobDTO{
+		Result:     result.String(),
+		UsedTime:   usedTime,
+		UsedMemory: usedMemory,
+		Error:      ErrStrIfNotNil(err),
+	}
+	if err := service.queue.Complete(job.Id, workerId, completeJudgeJobDTO); err != nil {
+		log.Error(err)
+		return
+	}
+
+	if result != JudgeUnknown && isSubmitWriteBack() {
+		saveSubmitResultDTO := SaveSubmitResultDTO{
+			SubmitId:   job.SubmitId,
+			Result:     result.String(),
+			UsedMemory: usedMemory,
+			UsedTime:   usedTime,
+		}
+		if err := service.submitRepository.SaveSubmitResult(saveSubmitResultDTO); err != nil {
+			log.Error(err)
+		}
+	}
+
+	if job.CallbackUrl != "" {
+		payload := NewCallbackPayload(dto, result, usedTime, usedMemory, err)
+		service.callbackService.Deliver(JobDeliveryId(job.Id), job.CallbackUrl, func() CallbackPayload {
+			return payload
+		})
+	}
+
+	log.Infow("채점 작업 완료", "jobId", job.Id, "submitId", job.SubmitId, "result", result.String())
+}
+
+// judge 는 채점 중 패닉이 나도 워커가 멈추지 않도록 복구해, 작업을 에러와 함께 끝난 것으로 남기게 한다
+func (service *WorkerService) judge(dto SubmitProblemDTO) (result JudgeResultEnum, usedTime int64, usedMemory int64, err error) {
+	defer recoverJudgeError("submit", dto.SubmitId, &err)
+
+	return service.problemService.SubmitProblem(context.Background(), dto)
+}
+
+// heartbeat 는 반환된 함수를 호출할 때까지 작업의 임대를 연장한다. 반환된 함수는 그 사이에 임대를 잃었는지 알려 준다
+func (service *WorkerService) heartbeat(workerId string, jobId int64) func() bool {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	var leaseLost bool
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(service.lease / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := service.queue.Heartbeat(jobId, workerId, service.lease); err != nil {
+					log.Error(err)
+					if errors.Is(err, repositories.ErrLeaseLost) {
+						leaseLost = true
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return func() bool {
+		close(done)
+		<-stopped
+		return leaseLost
+	}
+}
+
+func (service *WorkerService) requeueExpired() {
+	for {
+		time.Sleep(service.lease / 2)
+
+		requeued, err := service.queue.RequeueExpired(service.maxAttempts)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		if requeued > 0 {
+			log.Warnw("임대가 끝난 채점 작업을 다시 넣었습니다", "count", requeued)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	. "leita/src/utils"
+)
+
+const (
+	tracerName  = "leita"
+	serviceName = "leita-judge"
+)
+
+// Setup 은 OTLP 로 span 을 내보내는 TracerProvider 를 등록한다.
+// OTEL_EXPORTER_OTLP_ENDPOINT(또는 OTEL_EXPORTER_OTLP_TRACES_ENDPOINT) 가 없으면 span 은 만들지 않고 trace context 전파만 한다.
+// 예) OTEL_EXPORTER_OTLP_ENDPOINT=http://localhost:4318
+func Setup(ctx context.Context) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if GetEnv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && GetEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	// OTEL_SERVICE_NAME, OTEL_RESOURCE_ATTRIBUTES 가 있으면 기본값보다 우선한다
+	res, err := resource.Merge(
+		resource.NewSchemaless(semconv.ServiceName(serviceName)),
+		resource.Environment(),
+	)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartServer 는 외부에서 들어온 요청을 처리하는 span 을 시작한다
+func StartServer(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+}
+
+// End 는 err 가 있으면 span 에 기록하고 span 을 닫는다
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,15 @@
+//go:build linux
+
+package utils
+
+import "golang.org/x/sys/unix"
+
+// FreeDiskSpace 는 path 가 있는 파일 시스템에서 일반 사용자가 쓸 수 있는 남은 용량을 바이트로 반환한다
+func FreeDiskSpace(path string) (uint64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package utils
+
+import "errors"
+
+func FreeDiskSpace(_ string) (uint64, error) {
+	return 0, errors.New("free disk space is not supported on this platform")
+}
@@ -25,3 +25,13 @@ func Sum[T ~int | ~int64](s []T) T {
 	}
 	return sum
 }
+
+func Max[T ~int | ~int64](s []T) T {
+	var max T
+	for _, v := range s {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
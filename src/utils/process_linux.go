@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+
+	"github.com/gofiber/fiber/v2/log"
+	"golang.org/x/sys/unix"
+)
+
+func AvailableCPUs() []int {
+	var set unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &set); err != nil {
+		log.Error(err)
+		return defaultCPUs()
+	}
+
+	cpus := make([]int, 0, set.Count())
+	for cpu := 0; len(cpus) < set.Count(); cpu++ {
+		if set.IsSet(cpu) {
+			cpus = append(cpus, cpu)
+		}
+	}
+
+	return cpus
+}
+
+// StartPinned 는 현재 스레드의 affinity 를 잠시 바꾼 뒤 프로세스를 띄운다.
+// 자식 프로세스는 fork 한 스레드의 affinity 를 물려받으므로 시작부터 해당 코어에서만 돈다.
+func StartPinned(cmd *exec.Cmd, cpu int) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var original unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &original); err != nil {
+		log.Error(err)
+		return cmd.Start()
+	}
+
+	var pinned unix.CPUSet
+	pinned.Set(cpu)
+	if err := unix.SchedSetaffinity(0, &pinned); err != nil {
+		log.Error(err)
+		return cmd.Start()
+	}
+	defer func() {
+		if err := unix.SchedSetaffinity(0, &original); err != nil {
+			log.Error(err)
+		}
+	}()
+
+	return cmd.Start()
+}
+
+// UsedMemory 는 종료된 프로세스의 최대 RSS 를 KB 단위로 반환한다.
+func UsedMemory(state *os.ProcessState) int64 {
+	if state == nil {
+		return 0
+	}
+
+	if rusage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		return rusage.Maxrss
+	}
+
+	return 0
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package utils
+
+import (
+	"os"
+	"os/exec"
+)
+
+func AvailableCPUs() []int {
+	return defaultCPUs()
+}
+
+func StartPinned(cmd *exec.Cmd, _ int) error {
+	return cmd.Start()
+}
+
+func UsedMemory(_ *os.ProcessState) int64 {
+	return 0
+}
@@ -0,0 +1,312 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/gofiber/fiber/v2/log"
+)
+
+const streamBufferSize = 64 * 1024
+
+var ErrOutputLimitExceeded = errors.New("output limit exceeded")
+
+// EqualIgnoringTrailingWhitespace 는 두 스트림의 끝 공백을 무시하고 같은지 비교한다.
+// 한 바이트씩 읽기 때문에 파일 크기와 상관없이 일정한 메모리만 사용한다.
+func EqualIgnoringTrailingWhitespace(a, b io.Reader) (bool, error) {
+	readerA := bufio.NewReaderSize(a, streamBufferSize)
+	readerB := bufio.NewReaderSize(b, streamBufferSize)
+
+	for {
+		byteA, errA := readerA.ReadByte()
+		if errA != nil && !errors.Is(errA, io.EOF) {
+			log.Error(errA)
+			return false, errA
+		}
+
+		byteB, errB := readerB.ReadByte()
+		if errB != nil && !errors.Is(errB, io.EOF) {
+			log.Error(errB)
+			return false, errB
+		}
+
+		if errA == nil && errB == nil && byteA == byteB {
+			continue
+		}
+
+		if errA == nil {
+			_ = readerA.UnreadByte()
+		}
+		if errB == nil {
+			_ = readerB.UnreadByte()
+		}
+
+		restA, err := onlyWhitespace(readerA)
+		if err != nil {
+			log.Error(err)
+			return false, err
+		}
+
+		restB, err := onlyWhitespace(readerB)
+		if err != nil {
+			log.Error(err)
+			return false, err
+		}
+
+		return restA && restB, nil
+	}
+}
+
+// EqualTokens 는 testlib 의 std::wcmp.cpp 처럼 공백으로 나눈 토큰이 차례로 모두 같은지 비교한다.
+func EqualTokens(a, b io.Reader) (bool, error) {
+	readerA := bufio.NewReaderSize(a, streamBufferSize)
+	readerB := bufio.NewReaderSize(b, streamBufferSize)
+
+	for {
+		_, errA := skipBlanks(readerA, true)
+		if errA != nil && !errors.Is(errA, io.EOF) {
+			log.Error(errA)
+			return false, errA
+		}
+
+		_, errB := skipBlanks(readerB, true)
+		if errB != nil && !errors.Is(errB, io.EOF) {
+			log.Error(errB)
+			return false, errB
+		}
+
+		if errA != nil || errB != nil {
+			return errA != nil && errB != nil, nil
+		}
+
+		same, err := equalToken(readerA, readerB)
+		if err != nil {
+			log.Error(err)
+			return false, err
+		}
+		if !same {
+			return false, nil
+		}
+	}
+}
+
+// EqualLineTokens 는 testlib 의 std::lcmp.cpp 처럼 줄마다 공백으로 나눈 토큰이 같은지 비교한다.
+// 한쪽이 끝난 뒤에는 다른 쪽에 빈 줄만 남아 있어야 한다.
+func EqualLineTokens(a, b io.Reader) (bool, error) {
+	readerA := bufio.NewReaderSize(a, streamBufferSize)
+	readerB := bufio.NewReaderSize(b, streamBufferSize)
+
+	for {
+		nextA, errA := skipBlanks(readerA, false)
+		if errA != nil && !errors.Is(errA, io.EOF) {
+			log.Error(errA)
+			return false, errA
+		}
+
+		nextB, errB := skipBlanks(readerB, false)
+		if errB != nil && !errors.Is(errB, io.EOF) {
+			log.Error(errB)
+			return false, errB
+		}
+
+		lineEndA := errA != nil || nextA == '\n'
+		lineEndB := errB != nil || nextB == '\n'
+		if lineEndA != lineEndB {
+			return false, nil
+		}
+
+		if !lineEndA {
+			same, err := equalToken(readerA, readerB)
+			if err != nil {
+				log.Error(err)
+				return false, err
+			}
+			if !same {
+				return false, nil
+			}
+			continue
+		}
+
+		if errA != nil || errB != nil {
+			restA, err := onlyWhitespace(readerA)
+			if err != nil {
+				log.Error(err)
+				return false, err
+			}
+
+			restB, err := onlyWhitespace(readerB)
+			if err != nil {
+				log.Error(err)
+				return false, err
+			}
+
+			return restA && restB, nil
+		}
+
+		_, _ = readerA.ReadByte()
+		_, _ = readerB.ReadByte()
+	}
+}
+
+// skipBlanks 는 공백을 건너뛰고 다음 바이트를 읽지 않은 채로 돌려준다. newline 이 false 면 줄바꿈에서 멈춘다
+func skipBlanks(reader *bufio.Reader, newline bool) (byte, error) {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		if isBlank(b) && (newline || b != '\n') {
+			continue
+		}
+
+		_ = reader.UnreadByte()
+		return b, nil
+	}
+}
+
+// equalToken 은 두 스트림의 다음 토큰이 같은지 비교하고, 토큰 뒤의 공백은 읽지 않고 남겨 둔다
+func equalToken(readerA, readerB *bufio.Reader) (bool, error) {
+	for {
+		byteA, errA := readerA.ReadByte()
+		if errA != nil && !errors.Is(errA, io.EOF) {
+			return false, errA
+		}
+
+		byteB, errB := readerB.ReadByte()
+		if errB != nil && !errors.Is(errB, io.EOF) {
+			return false, errB
+		}
+
+		endA := errA != nil || isBlank(byteA)
+		endB := errB != nil || isBlank(byteB)
+		if endA && endB {
+			if errA == nil {
+				_ = readerA.UnreadByte()
+			}
+			if errB == nil {
+				_ = readerB.UnreadByte()
+			}
+			return true, nil
+		}
+
+		if endA || endB || byteA != byteB {
+			return false, nil
+		}
+	}
+}
+
+func isBlank(b byte) bool {
+	switch b {
+	case '\n', '\r', '\t', ' ':
+		return true
+	default:
+		return false
+	}
+}
+
+func onlyWhitespace(reader *bufio.Reader) (bool, error) {
+	for {
+		b, err := reader.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		switch b {
+		case '\n', '\r', '\t', ' ':
+		default:
+			return false, nil
+		}
+	}
+}
+
+// DecodeBase64ToFile 은 base64 스트림을 풀면서 바로 파일에 쓴다.
+func DecodeBase64ToFile(reader io.Reader, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	defer file.Close()
+
+	if _, err = io.Copy(file, base64.NewDecoder(base64.StdEncoding, reader)); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// ReadFilePreview 는 로그 출력용으로 파일 앞부분만 읽는다.
+func ReadFilePreview(path string, limit int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	defer file.Close()
+
+	preview, err := io.ReadAll(io.LimitReader(file, limit))
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return preview, nil
+}
+
+// LimitedWriter 는 limit 바이트까지만 writer 에 쓴다.
+// 넘치면 onExceed 를 한 번 부르고 ErrOutputLimitExceeded 를 반환하며, onExceed 가 nil 이면 넘친 부분은 조용히 버린다.
+type LimitedWriter struct {
+	writer   io.Writer
+	limit    int64
+	written  int64
+	exceeded bool
+	onExceed func()
+}
+
+func NewLimitedWriter(writer io.Writer, limit int64, onExceed func()) *LimitedWriter {
+	return &LimitedWriter{
+		writer:   writer,
+		limit:    limit,
+		onExceed: onExceed,
+	}
+}
+
+func (w *LimitedWriter) Write(p []byte) (int, error) {
+	if w.exceeded {
+		if w.onExceed == nil {
+			return len(p), nil
+		}
+		return 0, ErrOutputLimitExceeded
+	}
+
+	if remaining := w.limit - w.written; int64(len(p)) > remaining {
+		n, err := w.writer.Write(p[:remaining])
+		w.written += int64(n)
+		w.exceeded = true
+		if err != nil {
+			return n, err
+		}
+		if w.onExceed == nil {
+			return len(p), nil
+		}
+		w.onExceed()
+		return n, ErrOutputLimitExceeded
+	}
+
+	n, err := w.writer.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+// Exceeded 는 프로그램이 끝난 뒤에 출력이 limit 을 넘었는지 확인할 때 쓴다
+func (w *LimitedWriter) Exceeded() bool {
+	return w.exceeded
+}
@@ -2,8 +2,12 @@ package utils
 
 import (
 	"encoding/base64"
+	"io"
+	"io/fs"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 
@@ -98,6 +102,62 @@ func MakeDir(path string) error {
 	return nil
 }
 
+func DirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return size, nil
+}
+
+func CopyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	defer out.Close()
+
+	if _, err = io.Copy(out, in); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func defaultCPUs() []int {
+	cpus := make([]int, runtime.NumCPU())
+	for i := range cpus {
+		cpus[i] = i
+	}
+	return cpus
+}
+
 func RandomInt(min, max int) int {
 	return min + rand.Intn(max-min)
 }